@@ -6,7 +6,6 @@ package cmd
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -14,7 +13,6 @@ import (
 	"syscall"
 
 	"github.com/sighupio/furyctl/internal/bootstrap"
-	"github.com/sighupio/furyctl/internal/configuration"
 	"github.com/sighupio/furyctl/internal/project"
 	"github.com/sighupio/furyctl/pkg/analytics"
 	"github.com/sighupio/furyctl/pkg/terraform"
@@ -28,6 +26,10 @@ func bPreDestroy(cmd *cobra.Command, args []string) (err error) {
 		log.Warn("Force destroy of the bootstrap project")
 		return bPre(cmd, args)
 	}
+	if readsStdin(bConfigFilePaths) {
+		// the confirmation is read from the standard input too
+		return fmt.Errorf("the configuration is read from the standard input, confirm the destroy with --force")
+	}
 	fmt.Println("\r  Are you sure you want to destroy it?\n  Write 'yes' to continue")
 	reader := bufio.NewReader(os.Stdin)
 	text, err := reader.ReadString('\n')
@@ -53,7 +55,7 @@ func bPre(cmd *cobra.Command, args []string) (err error) {
 	}
 
 	log.Debug("passing pre-flight checks")
-	err = parseConfig(bConfigFilePath, "Bootstrap")
+	err = parseConfig(bConfigFilePaths, "Bootstrap", bAllowUnknownFields)
 	if err != nil {
 		log.Errorf("error parsing configuration file: %v", err)
 		return err
@@ -89,10 +91,14 @@ func bPre(cmd *cobra.Command, args []string) (err error) {
 var (
 	boot *bootstrap.Bootstrap
 
-	bConfigFilePath      string
+	bConfigFilePaths     []string
+	bAllowUnknownFields  bool
 	bWorkingDir          string
 	bGitHubToken         string
 	bTemplateProvisioner string
+	bTemplateMinimal     bool
+	bTemplateFull        bool
+	bTemplateOutput      string
 	bReset               bool
 	bReconfigure         bool
 	bDryRun              bool
@@ -114,15 +120,7 @@ var (
 		Use:   "template",
 		Short: "Get a template configuration file for a specific provisioner",
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			if bTemplateProvisioner == "" {
-				return errors.New("You must specify a provisioner")
-			}
-			tpl, err := configuration.Template("Bootstrap", bTemplateProvisioner)
-			if err != nil {
-				return err
-			}
-			fmt.Println(tpl)
-			return nil
+			return writeTemplate("Bootstrap", bTemplateProvisioner, bTemplateMinimal, bTemplateFull, bTemplateOutput)
 		},
 	}
 	bootstrapInitCmd = &cobra.Command{
@@ -188,9 +186,13 @@ var (
 func init() {
 	bootstrapApplyCmd.PersistentFlags().BoolVar(&bDryRun, "dry-run", false, "Dry run execution")
 
-	bootstrapInitCmd.PersistentFlags().StringVarP(&bConfigFilePath, "config", "c", "bootstrap.yml", "Bootstrap configuration file path")
-	bootstrapApplyCmd.PersistentFlags().StringVarP(&bConfigFilePath, "config", "c", "bootstrap.yml", "Bootstrap configuration file path")
-	bootstrapDestroyCmd.PersistentFlags().StringVarP(&bConfigFilePath, "config", "c", "bootstrap.yml", "Bootstrap configuration file path")
+	bootstrapInitCmd.PersistentFlags().StringArrayVarP(&bConfigFilePaths, "config", "c", []string{"bootstrap.yml"}, "Bootstrap configuration file path, - for the standard input or a go-getter URL. Repeat it to merge overlays into the first file")
+	bootstrapApplyCmd.PersistentFlags().StringArrayVarP(&bConfigFilePaths, "config", "c", []string{"bootstrap.yml"}, "Bootstrap configuration file path, - for the standard input or a go-getter URL. Repeat it to merge overlays into the first file")
+	bootstrapDestroyCmd.PersistentFlags().StringArrayVarP(&bConfigFilePaths, "config", "c", []string{"bootstrap.yml"}, "Bootstrap configuration file path, - for the standard input or a go-getter URL. Repeat it to merge overlays into the first file")
+
+	bootstrapInitCmd.PersistentFlags().BoolVar(&bAllowUnknownFields, "allow-unknown-fields", false, "Warn about the unknown fields of the configuration files instead of failing")
+	bootstrapApplyCmd.PersistentFlags().BoolVar(&bAllowUnknownFields, "allow-unknown-fields", false, "Warn about the unknown fields of the configuration files instead of failing")
+	bootstrapDestroyCmd.PersistentFlags().BoolVar(&bAllowUnknownFields, "allow-unknown-fields", false, "Warn about the unknown fields of the configuration files instead of failing")
 
 	bootstrapInitCmd.PersistentFlags().StringVarP(&bWorkingDir, "workdir", "w", "./bootstrap", "Working directory to create and place all project files. Must not exists.")
 	bootstrapApplyCmd.PersistentFlags().StringVarP(&bWorkingDir, "workdir", "w", "./bootstrap", "Working directory with all project files")
@@ -209,6 +211,9 @@ func init() {
 	bootstrapDestroyCmd.PersistentFlags().BoolVar(&bForce, "force", false, "Forces the destroy of the project. Doesn't ask for confirmation")
 
 	bootstrapTemplateCmd.PersistentFlags().StringVar(&bTemplateProvisioner, "provisioner", "", "Bootstrap provisioner")
+	bootstrapTemplateCmd.PersistentFlags().BoolVar(&bTemplateMinimal, "minimal", false, "Write only the required fields")
+	bootstrapTemplateCmd.PersistentFlags().BoolVar(&bTemplateFull, "full", false, "Write every field, the default")
+	bootstrapTemplateCmd.PersistentFlags().StringVarP(&bTemplateOutput, "output", "o", "", "Write the template to a file instead of the standard output")
 
 	bootstrapCmd.AddCommand(bootstrapInitCmd)
 	bootstrapCmd.AddCommand(bootstrapApplyCmd)
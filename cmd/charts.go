@@ -0,0 +1,469 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	chartsKind        = "charts"
+	chartsLockFile    = ".furyctl-charts.lock.yml"
+	ociPrefix         = "oci://"
+	ociManifestType   = "application/vnd.oci.image.manifest.v1+json"
+	helmChartLayer    = "application/vnd.cncf.helm.chart.content.v1.tar+gzip"
+	chartsHTTPTimeout = 60 * time.Second
+)
+
+var chartsHTTPClient = &http.Client{Timeout: chartsHTTPTimeout}
+
+// Chart is the type to contain the definition of a single helm chart
+type Chart struct {
+	Name       string `yaml:"name"`
+	Chart      string `yaml:"chart"`
+	Repository string `yaml:"repository"`
+	OCI        string `yaml:"oci"`
+	Version    string `yaml:"version"`
+	Hooks      Hooks  `yaml:"hooks"`
+}
+
+// chartName returns the name of the chart inside the repository
+func (c Chart) chartName() string {
+	if c.Chart != "" {
+		return c.Chart
+	}
+	return c.Name
+}
+
+// source returns a human readable reference of where the chart comes from
+func (c Chart) source() string {
+	if c.OCI != "" {
+		return c.OCI
+	}
+	return fmt.Sprintf("%s#%s", strings.TrimSuffix(c.Repository, "/"), c.chartName())
+}
+
+func (c Chart) validate() error {
+	switch {
+	case c.Name == "":
+		return errors.New("chart without name")
+	case strings.Contains(c.Name, "..") || strings.HasPrefix(c.Name, "/"):
+		// the name is the directory of the chart inside the vendor folder
+		return fmt.Errorf("chart %s name can not contain .. or start with /", c.Name)
+	case c.Repository == "" && c.OCI == "":
+		return fmt.Errorf("chart %s requires a repository or an oci reference", c.Name)
+	case c.Repository != "" && c.OCI != "":
+		return fmt.Errorf("chart %s can not set both repository and oci", c.Name)
+	case c.OCI != "" && !strings.HasPrefix(c.OCI, ociPrefix):
+		return fmt.Errorf("chart %s oci reference must start with %s", c.Name, ociPrefix)
+	}
+	return nil
+}
+
+// chartIndex is the abstraction of the index.yaml file served by a chart repository
+type chartIndex struct {
+	Entries map[string][]chartIndexEntry `yaml:"entries"`
+}
+
+type chartIndexEntry struct {
+	Name    string   `yaml:"name"`
+	Version string   `yaml:"version"`
+	Digest  string   `yaml:"digest"`
+	URLs    []string `yaml:"urls"`
+}
+
+// chartLock records the digest of every vendored chart
+type chartLock struct {
+	Charts map[string]chartLockEntry `yaml:"charts"`
+}
+
+type chartLockEntry struct {
+	Version string `yaml:"version"`
+	Source  string `yaml:"source"`
+	Digest  string `yaml:"digest"`
+}
+
+var chartsLockMutex sync.Mutex
+
+// getChart downloads, verifies and unpacks a chart into its vendor directory
+func getChart(c Chart, version, dest string) error {
+	if version == "" {
+		return fmt.Errorf("chart %s has no version", c.Name)
+	}
+	logrus.Infof("downloading chart: %s@%s -> %s", c.source(), version, dest)
+
+	var archive []byte
+	var digest string
+	var err error
+	if c.OCI != "" {
+		archive, digest, err = pullOCIChart(c.OCI, version)
+	} else {
+		archive, digest, err = pullRepositoryChart(c.Repository, c.chartName(), version)
+	}
+	if err != nil {
+		return fmt.Errorf("chart %s: %v", c.Name, err)
+	}
+
+	err = checkChartLock(filepath.Dir(dest), c, version, digest)
+	if err != nil {
+		return err
+	}
+
+	tempDest := dest + ".tmp"
+	err = removeDir(tempDest)
+	if err != nil {
+		return err
+	}
+	err = untarChart(archive, tempDest)
+	if err != nil {
+		_ = removeDir(tempDest)
+		return fmt.Errorf("chart %s: %v", c.Name, err)
+	}
+	err = renameDir(tempDest, dest)
+	if err != nil {
+		return err
+	}
+	return writeChartLock(filepath.Dir(dest), c, version, digest)
+}
+
+// pullRepositoryChart gets the chart archive from a chart repository verifying it against the index digest
+func pullRepositoryChart(repository, name, version string) ([]byte, string, error) {
+	base, err := url.Parse(strings.TrimSuffix(repository, "/") + "/")
+	if err != nil {
+		return nil, "", err
+	}
+	indexURL, _ := base.Parse("index.yaml")
+	content, err := httpGet(indexURL.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	index := chartIndex{}
+	err = yaml.Unmarshal(content, &index)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to decode %s: %v", indexURL, err)
+	}
+	var entry *chartIndexEntry
+	for i, e := range index.Entries[name] {
+		if e.Version == version {
+			entry = &index.Entries[name][i]
+			break
+		}
+	}
+	if entry == nil {
+		return nil, "", fmt.Errorf("version %s of %s not found in %s", version, name, indexURL)
+	}
+	if len(entry.URLs) == 0 {
+		return nil, "", fmt.Errorf("no urls for %s@%s in %s", name, version, indexURL)
+	}
+	if entry.Digest == "" {
+		return nil, "", fmt.Errorf("no digest for %s@%s in %s", name, version, indexURL)
+	}
+	archiveURL, err := base.Parse(entry.URLs[0])
+	if err != nil {
+		return nil, "", err
+	}
+	archive, err := httpGet(archiveURL.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	digest := "sha256:" + strings.TrimPrefix(entry.Digest, "sha256:")
+	err = verifyDigest(archive, digest)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %v", archiveURL, err)
+	}
+	return archive, digest, nil
+}
+
+// ociManifest is the subset of an OCI image manifest needed to find the chart layer
+type ociManifest struct {
+	Layers []struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+	} `json:"layers"`
+}
+
+// pullOCIChart gets the chart archive from an OCI registry verifying it against the manifest digest
+func pullOCIChart(reference, version string) ([]byte, string, error) {
+	ref := strings.TrimPrefix(reference, ociPrefix)
+	slash := strings.Index(ref, "/")
+	if slash < 0 {
+		return nil, "", fmt.Errorf("invalid oci reference %s", reference)
+	}
+	registry, repository := ref[:slash], ref[slash+1:]
+	// OCI tags can't contain "+", helm replaces it with "_"
+	tag := strings.Replace(version, "+", "_", -1)
+
+	headers := map[string]string{"Accept": ociManifestType}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, tag)
+	content, err := ociGet(manifestURL, headers)
+	if err != nil {
+		return nil, "", err
+	}
+	manifest := ociManifest{}
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		return nil, "", fmt.Errorf("unable to decode the manifest of %s:%s: %v", reference, tag, err)
+	}
+	for _, layer := range manifest.Layers {
+		if layer.MediaType != helmChartLayer {
+			continue
+		}
+		archive, err := ociGet(fmt.Sprintf("https://%s/v2/%s/blobs/%s", registry, repository, layer.Digest), headers)
+		if err != nil {
+			return nil, "", err
+		}
+		err = verifyDigest(archive, layer.Digest)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s:%s: %v", reference, tag, err)
+		}
+		return archive, layer.Digest, nil
+	}
+	return nil, "", fmt.Errorf("%s:%s is not a helm chart", reference, tag)
+}
+
+// ociGet performs a GET request against a registry, obtaining an anonymous token when requested
+func ociGet(u string, headers map[string]string) ([]byte, error) {
+	content, err := httpGet(u, headers)
+	authErr, ok := err.(*httpUnauthorizedError)
+	if !ok {
+		return content, err
+	}
+	token, err := ociToken(authErr.challenge)
+	if err != nil {
+		return nil, err
+	}
+	authHeaders := map[string]string{"Authorization": "Bearer " + token}
+	for k, v := range headers {
+		authHeaders[k] = v
+	}
+	return httpGet(u, authHeaders)
+}
+
+// ociToken requests an anonymous bearer token following a WWW-Authenticate challenge
+func ociToken(challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported registry authentication: %s", challenge)
+	}
+	params := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) == 2 {
+			params[kv[0]] = strings.Trim(kv[1], "\"")
+		}
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid registry authentication realm: %s", challenge)
+	}
+	q := realm.Query()
+	for _, k := range []string{"service", "scope"} {
+		if params[k] != "" {
+			q.Set(k, params[k])
+		}
+	}
+	realm.RawQuery = q.Encode()
+	content, err := httpGet(realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	err = json.Unmarshal(content, &token)
+	if err != nil {
+		return "", err
+	}
+	if token.Token != "" {
+		return token.Token, nil
+	}
+	return token.AccessToken, nil
+}
+
+type httpUnauthorizedError struct {
+	url       string
+	challenge string
+}
+
+func (e *httpUnauthorizedError) Error() string {
+	return fmt.Sprintf("unauthorized request to %s", e.url)
+}
+
+type httpNotFoundError struct {
+	url    string
+	status string
+}
+
+func (e *httpNotFoundError) Error() string {
+	return fmt.Sprintf("GET %s: %s", e.url, e.status)
+}
+
+func httpGet(u string, headers map[string]string) ([]byte, error) {
+	content, _, err := httpGetResponse(u, headers)
+	return content, err
+}
+
+// httpGetResponse is httpGet returning the headers of the response too
+func httpGetResponse(u string, headers map[string]string) ([]byte, http.Header, error) {
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := chartsHTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, nil, &httpUnauthorizedError{url: u, challenge: resp.Header.Get("WWW-Authenticate")}
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil, &httpNotFoundError{url: u, status: resp.Status}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	content, err := ioutil.ReadAll(resp.Body)
+	return content, resp.Header, err
+}
+
+func verifyDigest(content []byte, digest string) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return fmt.Errorf("unsupported digest %s", digest)
+	}
+	sum := sha256.Sum256(content)
+	got := hex.EncodeToString(sum[:])
+	if got != strings.TrimPrefix(digest, "sha256:") {
+		return fmt.Errorf("digest mismatch: expected %s, got sha256:%s", digest, got)
+	}
+	return nil
+}
+
+// untarChart unpacks a chart archive removing the top level chart directory
+func untarChart(archive []byte, dest string) error {
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		name := path.Clean(hdr.Name)
+		parts := strings.SplitN(name, "/", 2)
+		if len(parts) < 2 || parts[1] == "" {
+			continue
+		}
+		rel := filepath.FromSlash(parts[1])
+		if strings.HasPrefix(rel, "..") || filepath.IsAbs(rel) {
+			return fmt.Errorf("invalid path in chart archive: %s", hdr.Name)
+		}
+		target := filepath.Join(dest, rel)
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(target, 0755)
+		case tar.TypeReg:
+			err = writeChartFile(target, tr, os.FileMode(hdr.Mode).Perm())
+		default:
+			logrus.Debugf("skipping %s from chart archive", hdr.Name)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeChartFile(target string, r io.Reader, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(target), 0755)
+	if err != nil {
+		return err
+	}
+	if mode == 0 {
+		mode = 0644
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, r)
+	return err
+}
+
+func readChartLock(chartsDir string) (chartLock, error) {
+	lock := chartLock{Charts: map[string]chartLockEntry{}}
+	content, err := ioutil.ReadFile(filepath.Join(chartsDir, chartsLockFile))
+	if os.IsNotExist(err) {
+		return lock, nil
+	}
+	if err != nil {
+		return lock, err
+	}
+	err = yaml.Unmarshal(content, &lock)
+	if lock.Charts == nil {
+		lock.Charts = map[string]chartLockEntry{}
+	}
+	return lock, err
+}
+
+// checkChartLock fails if the same version of a chart was previously vendored with a different digest
+func checkChartLock(chartsDir string, c Chart, version, digest string) error {
+	chartsLockMutex.Lock()
+	defer chartsLockMutex.Unlock()
+	lock, err := readChartLock(chartsDir)
+	if err != nil {
+		return err
+	}
+	locked, ok := lock.Charts[c.Name]
+	if ok && locked.Version == version && locked.Source == c.source() && locked.Digest != digest {
+		return fmt.Errorf("chart %s@%s digest changed from %s to %s. Remove it from %s to accept the new content", c.Name, version, locked.Digest, digest, filepath.Join(chartsDir, chartsLockFile))
+	}
+	return nil
+}
+
+func writeChartLock(chartsDir string, c Chart, version, digest string) error {
+	chartsLockMutex.Lock()
+	defer chartsLockMutex.Unlock()
+	lock, err := readChartLock(chartsDir)
+	if err != nil {
+		return err
+	}
+	lock.Charts[c.Name] = chartLockEntry{Version: version, Source: c.source(), Digest: digest}
+	content, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(chartsDir, chartsLockFile), content, 0644)
+}
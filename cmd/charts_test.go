@@ -0,0 +1,277 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func chartArchive(t *testing.T, name, version string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	files := map[string]string{
+		name + "/Chart.yaml":             fmt.Sprintf("apiVersion: v2\nname: %s\nversion: %s\n", name, version),
+		name + "/templates/service.yaml": "kind: Service\n",
+	}
+	for fileName, content := range files {
+		err := tw.WriteHeader(&tar.Header{Name: fileName, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// chartRepository serves an index.yaml and the archives of a local chart repository
+func chartRepository(t *testing.T, archives map[string][]byte, digests map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/index.yaml" {
+			index := "apiVersion: v1\nentries:\n  podinfo:\n"
+			for version := range archives {
+				index += fmt.Sprintf("  - name: podinfo\n    version: %s\n    digest: %s\n    urls:\n    - podinfo-%s.tgz\n", version, digests[version], version)
+			}
+			fmt.Fprint(w, index)
+			return
+		}
+		for version, archive := range archives {
+			if r.URL.Path == fmt.Sprintf("/podinfo-%s.tgz", version) {
+				w.Write(archive)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	}))
+}
+
+func sha256Hex(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestGetChartFromRepository(t *testing.T) {
+	archive := chartArchive(t, "podinfo", "6.0.0")
+	srv := chartRepository(t, map[string][]byte{"6.0.0": archive}, map[string]string{"6.0.0": sha256Hex(archive)})
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "furyctl-charts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "charts", "podinfo")
+
+	c := Chart{Name: "podinfo", Repository: srv.URL}
+	if err = getChart(c, "6.0.0", dest); err != nil {
+		t.Fatalf("getChart() error = %v", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dest, "Chart.yaml"))
+	if err != nil {
+		t.Fatalf("chart not unpacked: %v", err)
+	}
+	if !strings.Contains(string(content), "version: 6.0.0") {
+		t.Errorf("unexpected Chart.yaml content: %s", content)
+	}
+	if _, err = os.Stat(filepath.Join(dest, "templates", "service.yaml")); err != nil {
+		t.Errorf("chart templates not unpacked: %v", err)
+	}
+	lock, err := readChartLock(filepath.Join(dir, "charts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lock.Charts["podinfo"].Digest; got != "sha256:"+sha256Hex(archive) {
+		t.Errorf("locked digest = %s, want sha256:%s", got, sha256Hex(archive))
+	}
+
+	if err = getChart(c, "7.0.0", dest); err == nil {
+		t.Error("getChart() of a missing version should fail")
+	}
+}
+
+func TestGetChartDigestMismatch(t *testing.T) {
+	archive := chartArchive(t, "podinfo", "6.0.0")
+	srv := chartRepository(t, map[string][]byte{"6.0.0": archive}, map[string]string{"6.0.0": sha256Hex([]byte("tampered"))})
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "furyctl-charts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dest := filepath.Join(dir, "charts", "podinfo")
+
+	err = getChart(Chart{Name: "podinfo", Repository: srv.URL}, "6.0.0", dest)
+	if err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Fatalf("getChart() error = %v, want a digest mismatch", err)
+	}
+	if _, err = os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("chart with a wrong digest should not be unpacked")
+	}
+}
+
+func TestGetChartLockedDigestChanged(t *testing.T) {
+	archive := chartArchive(t, "podinfo", "6.0.0")
+	srv := chartRepository(t, map[string][]byte{"6.0.0": archive}, map[string]string{"6.0.0": sha256Hex(archive)})
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "furyctl-charts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	c := Chart{Name: "podinfo", Repository: srv.URL}
+	if err = os.MkdirAll(filepath.Join(dir, "charts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = writeChartLock(filepath.Join(dir, "charts"), c, "6.0.0", "sha256:"+sha256Hex([]byte("previous"))); err != nil {
+		t.Fatal(err)
+	}
+
+	err = getChart(c, "6.0.0", filepath.Join(dir, "charts", "podinfo"))
+	if err == nil || !strings.Contains(err.Error(), "digest changed") {
+		t.Fatalf("getChart() error = %v, want a changed digest error", err)
+	}
+}
+
+// ociRegistry serves a chart from a local OCI registry requiring an anonymous bearer token
+func ociRegistry(t *testing.T, archive []byte, layerDigest string) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:charts/podinfo:pull" {
+				http.Error(w, "wrong scope", http.StatusBadRequest)
+				return
+			}
+			fmt.Fprint(w, `{"token": "anonymous"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer anonymous" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:charts/podinfo:pull"`, srv.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v2/charts/podinfo/manifests/6.0.0_1":
+			if r.Header.Get("Accept") != ociManifestType {
+				http.Error(w, "wrong media type", http.StatusNotAcceptable)
+				return
+			}
+			fmt.Fprintf(w, `{"schemaVersion": 2, "layers": [{"mediaType": "application/vnd.cncf.helm.chart.provenance.v1.prov", "digest": "sha256:0"}, {"mediaType": "%s", "digest": "%s"}]}`, helmChartLayer, layerDigest)
+		case "/v2/charts/podinfo/blobs/" + layerDigest:
+			w.Write(archive)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return srv
+}
+
+func TestGetChartFromOCI(t *testing.T) {
+	archive := chartArchive(t, "podinfo", "6.0.0+1")
+
+	tests := []struct {
+		name     string
+		digest   string
+		errorMsg string
+	}{
+		{name: "valid", digest: "sha256:" + sha256Hex(archive)},
+		{name: "digest mismatch", digest: "sha256:" + sha256Hex([]byte("tampered")), errorMsg: "digest mismatch"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := ociRegistry(t, archive, tt.digest)
+			defer srv.Close()
+			defer func(c *http.Client) { chartsHTTPClient = c }(chartsHTTPClient)
+			chartsHTTPClient = srv.Client()
+
+			dir, err := ioutil.TempDir("", "furyctl-charts")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			dest := filepath.Join(dir, "charts", "podinfo")
+
+			c := Chart{Name: "podinfo", OCI: ociPrefix + strings.TrimPrefix(srv.URL, "https://") + "/charts/podinfo"}
+			err = getChart(c, "6.0.0+1", dest)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("getChart() error = %v, want %q", err, tt.errorMsg)
+				}
+				if _, err = os.Stat(dest); !os.IsNotExist(err) {
+					t.Errorf("chart with a wrong digest should not be unpacked")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("getChart() error = %v", err)
+			}
+			content, err := ioutil.ReadFile(filepath.Join(dest, "Chart.yaml"))
+			if err != nil || !strings.Contains(string(content), "version: 6.0.0+1") {
+				t.Errorf("unexpected Chart.yaml content: %s, %v", content, err)
+			}
+			lock, err := readChartLock(filepath.Join(dir, "charts"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := lock.Charts["podinfo"]; got.Digest != tt.digest || got.Source != c.OCI {
+				t.Errorf("locked chart = %+v, want %s from %s", got, tt.digest, c.OCI)
+			}
+		})
+	}
+
+	if _, _, err := pullOCIChart("oci://registry.example.com", "1.0.0"); err == nil || !strings.Contains(err.Error(), "invalid oci reference") {
+		t.Errorf("pullOCIChart() error = %v, want an invalid reference", err)
+	}
+}
+
+func TestChartValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		chart    Chart
+		errorMsg string
+	}{
+		{name: "repository", chart: Chart{Name: "ingress-nginx", Repository: "https://kubernetes.github.io/ingress-nginx"}},
+		{name: "oci", chart: Chart{Name: "podinfo", OCI: "oci://ghcr.io/stefanprodan/charts/podinfo"}},
+		{name: "without name", chart: Chart{Repository: "https://kubernetes.github.io/ingress-nginx"}, errorMsg: "chart without name"},
+		{name: "parent directory", chart: Chart{Name: "../../bin", Repository: "https://example.com"}, errorMsg: "can not contain .. or start with /"},
+		{name: "absolute path", chart: Chart{Name: "/etc/podinfo", Repository: "https://example.com"}, errorMsg: "can not contain .. or start with /"},
+		{name: "without source", chart: Chart{Name: "podinfo"}, errorMsg: "requires a repository or an oci reference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.chart.validate()
+			if tt.errorMsg == "" {
+				if err != nil {
+					t.Errorf("validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+				t.Errorf("validate() error = %v, want %q", err, tt.errorMsg)
+			}
+		})
+	}
+}
@@ -6,7 +6,6 @@ package cmd
 
 import (
 	"bufio"
-	"errors"
 	"fmt"
 	"os"
 	"os/signal"
@@ -14,7 +13,6 @@ import (
 	"syscall"
 
 	"github.com/sighupio/furyctl/internal/cluster"
-	"github.com/sighupio/furyctl/internal/configuration"
 	"github.com/sighupio/furyctl/internal/project"
 	"github.com/sighupio/furyctl/pkg/analytics"
 	"github.com/sighupio/furyctl/pkg/terraform"
@@ -28,6 +26,10 @@ func cPreDestroy(cmd *cobra.Command, args []string) (err error) {
 		log.Warn("Force destroy of the cluster project")
 		return cPre(cmd, args)
 	}
+	if readsStdin(cConfigFilePaths) {
+		// the confirmation is read from the standard input too
+		return fmt.Errorf("the configuration is read from the standard input, confirm the destroy with --force")
+	}
 	fmt.Println("\r  Are you sure you want to destroy the cluster?\n  Write 'yes' to continue")
 	reader := bufio.NewReader(os.Stdin)
 	text, err := reader.ReadString('\n')
@@ -53,7 +55,7 @@ func cPre(cmd *cobra.Command, args []string) (err error) {
 	}
 
 	log.Debug("passing pre-flight checks")
-	err = parseConfig(cConfigFilePath, "Cluster")
+	err = parseConfig(cConfigFilePaths, "Cluster", cAllowUnknownFields)
 	if err != nil {
 		log.Errorf("error parsing configuration file: %v", err)
 		return err
@@ -74,7 +76,7 @@ func cPre(cmd *cobra.Command, args []string) (err error) {
 		TerraformOpts: &terraform.Options{
 			GitHubToken:        cGitHubToken,
 			WorkingDir:         workingDirFullPath,
-			Debug:              cDryRun,
+			Debug:              debug,
 			ReconfigureBackend: cReconfigure,
 		},
 	}
@@ -89,10 +91,14 @@ func cPre(cmd *cobra.Command, args []string) (err error) {
 var (
 	clu *cluster.Cluster
 
-	cConfigFilePath      string
+	cConfigFilePaths     []string
+	cAllowUnknownFields  bool
 	cWorkingDir          string
 	cGitHubToken         string
 	cTemplateProvisioner string
+	cTemplateMinimal     bool
+	cTemplateFull        bool
+	cTemplateOutput      string
 	cDryRun              bool
 	cReset               bool
 	cReconfigure         bool
@@ -114,15 +120,7 @@ var (
 		Use:   "template",
 		Short: "Get a template configuration file for a specific provisioner",
 		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			if cTemplateProvisioner == "" {
-				return errors.New("You must specify a provisioner")
-			}
-			tpl, err := configuration.Template("Cluster", cTemplateProvisioner)
-			if err != nil {
-				return err
-			}
-			fmt.Println(tpl)
-			return nil
+			return writeTemplate("Cluster", cTemplateProvisioner, cTemplateMinimal, cTemplateFull, cTemplateOutput)
 		},
 	}
 	clusterInitCmd = &cobra.Command{
@@ -190,9 +188,13 @@ var (
 func init() {
 	clusterApplyCmd.PersistentFlags().BoolVar(&cDryRun, "dry-run", false, "Dry run execution")
 
-	clusterInitCmd.PersistentFlags().StringVarP(&cConfigFilePath, "config", "c", "cluster.yml", "Cluster configuration file path")
-	clusterApplyCmd.PersistentFlags().StringVarP(&cConfigFilePath, "config", "c", "cluster.yml", "Cluster configuration file path")
-	clusterDestroyCmd.PersistentFlags().StringVarP(&cConfigFilePath, "config", "c", "cluster.yml", "Cluster configuration file path")
+	clusterInitCmd.PersistentFlags().StringArrayVarP(&cConfigFilePaths, "config", "c", []string{"cluster.yml"}, "Cluster configuration file path, - for the standard input or a go-getter URL. Repeat it to merge overlays into the first file")
+	clusterApplyCmd.PersistentFlags().StringArrayVarP(&cConfigFilePaths, "config", "c", []string{"cluster.yml"}, "Cluster configuration file path, - for the standard input or a go-getter URL. Repeat it to merge overlays into the first file")
+	clusterDestroyCmd.PersistentFlags().StringArrayVarP(&cConfigFilePaths, "config", "c", []string{"cluster.yml"}, "Cluster configuration file path, - for the standard input or a go-getter URL. Repeat it to merge overlays into the first file")
+
+	clusterInitCmd.PersistentFlags().BoolVar(&cAllowUnknownFields, "allow-unknown-fields", false, "Warn about the unknown fields of the configuration files instead of failing")
+	clusterApplyCmd.PersistentFlags().BoolVar(&cAllowUnknownFields, "allow-unknown-fields", false, "Warn about the unknown fields of the configuration files instead of failing")
+	clusterDestroyCmd.PersistentFlags().BoolVar(&cAllowUnknownFields, "allow-unknown-fields", false, "Warn about the unknown fields of the configuration files instead of failing")
 
 	clusterInitCmd.PersistentFlags().StringVarP(&cWorkingDir, "workdir", "w", "./cluster", "Working directory to create and place all project files. Must not exists.")
 	clusterApplyCmd.PersistentFlags().StringVarP(&cWorkingDir, "workdir", "w", "./cluster", "Working directory with all project files")
@@ -211,6 +213,9 @@ func init() {
 	clusterDestroyCmd.PersistentFlags().BoolVar(&cForce, "force", false, "Forces the destroy of the project. Doesn't ask for confirmation")
 
 	clusterTemplateCmd.PersistentFlags().StringVar(&cTemplateProvisioner, "provisioner", "", "Cluster provisioner")
+	clusterTemplateCmd.PersistentFlags().BoolVar(&cTemplateMinimal, "minimal", false, "Write only the required fields")
+	clusterTemplateCmd.PersistentFlags().BoolVar(&cTemplateFull, "full", false, "Write every field, the default")
+	clusterTemplateCmd.PersistentFlags().StringVarP(&cTemplateOutput, "output", "o", "", "Write the template to a file instead of the standard output")
 
 	clusterCmd.AddCommand(clusterInitCmd)
 	clusterCmd.AddCommand(clusterApplyCmd)
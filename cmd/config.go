@@ -26,7 +26,11 @@ type Furyconf struct {
 	Roles            []Package       `yaml:"roles"`
 	Modules          []Package       `yaml:"modules"`
 	Bases            []Package       `yaml:"bases"`
+	Charts           []Chart         `yaml:"charts"`
 	Provider         ProviderPattern `mapstructure:"provider"`
+	SSH              SSHConf         `yaml:"ssh"`
+	Hooks            Hooks           `yaml:"hooks"`
+	replace          map[string]string
 }
 
 // ProviderPattern is the abstraction of the following structure:
@@ -51,18 +55,27 @@ type RegistrySpec struct {
 	Label   string `mapstructure:"label"`
 }
 
-//VersionPattern Map from glob pattern to version associated (e.g. {"aws/*" : "v1.15.4-1"}
+//VersionPattern Map from glob pattern to version associated (e.g. {"aws/*" : "v1.15.4-1"}.
+// Patterns support "*" within a path segment and "**" across segments, see Resolve for the precedence rules
 type VersionPattern map[string]string
 
 // Package is the type to contain the definition of a single package
 type Package struct {
-	Name        string `yaml:"name"`
-	Version     string `yaml:"version"`
-	url         string
-	dir         string
-	kind        string
-	ProviderOpt ProviderOptSpec `mapstructure:"provider"`
-	Registry    bool            `mapstructure:"registry"`
+	Name            string `yaml:"name"`
+	Version         string `yaml:"version"`
+	url             string
+	dir             string
+	kind            string
+	override        string
+	chart           *Chart
+	// versionPattern is the key of the versions map that supplied the version
+	versionPattern  string
+	resolvedVersion string
+	// effectiveHooks are the global hooks followed by the package ones
+	effectiveHooks  Hooks
+	ProviderOpt     ProviderOptSpec `mapstructure:"provider"`
+	Registry        bool            `mapstructure:"registry"`
+	Hooks           Hooks           `yaml:"hooks"`
 }
 
 // ProviderSpec is the type that allows to explicit name of cloud provider and referenced label
@@ -114,14 +127,16 @@ func (f *Furyconf) Parse(prefix string) ([]Package, error) {
 		version := pkgs[i].Version
 
 		if version == "" {
-			for k, v := range f.Versions {
-				if strings.HasPrefix(pkgs[i].Name, k) {
-					version = v
-					logrus.Infof("using %v for package %s", version, pkgs[i].Name)
-					break
-				}
+			var ambiguous []string
+			version, pkgs[i].versionPattern, ambiguous = f.Versions.Resolve(pkgs[i].Name)
+			if len(ambiguous) > 0 {
+				logrus.Warnf("ambiguous version patterns for package %s: %s wins over %s", pkgs[i].Name, pkgs[i].versionPattern, strings.Join(ambiguous, ", "))
+			}
+			if version != "" {
+				logrus.Infof("using %v for package %s from pattern %s", version, pkgs[i].Name, pkgs[i].versionPattern)
 			}
 		}
+		pkgs[i].resolvedVersion = version
 		registry := pkgs[i].Registry
 		cloudPlatform := pkgs[i].ProviderOpt
 		pkgKind := pkgs[i].kind
@@ -130,6 +145,46 @@ func (f *Furyconf) Parse(prefix string) ([]Package, error) {
 
 		pkgs[i].dir = newDir(f.VendorFolderName, pkgKind, pkgs[i].Name, registry, cloudPlatform).getConsumableDirectory()
 
+		if localPath, ok := f.replace[pkgs[i].Name]; ok {
+			pkgs[i].override = localPath
+		}
+
+		hooks, err := mergeHooks(f.Hooks, pkgs[i].Hooks)
+		if err != nil {
+			return nil, fmt.Errorf("package %s: %v", pkgs[i].Name, err)
+		}
+		pkgs[i].effectiveHooks = hooks
+	}
+
+	for _, c := range f.Charts {
+		if !strings.HasPrefix(c.Name, prefix) {
+			continue
+		}
+		if err := c.validate(); err != nil {
+			return nil, err
+		}
+		chart := c
+		p := Package{
+			Name:    c.Name,
+			Version: c.Version,
+			kind:    chartsKind,
+			url:     c.source(),
+			dir:     fmt.Sprintf("%s/%s/%s", f.VendorFolderName, chartsKind, c.Name),
+			chart:   &chart,
+		}
+		p.resolvedVersion = c.Version
+		if p.resolvedVersion == "" {
+			p.resolvedVersion, p.versionPattern, _ = f.Versions.Resolve(c.Name)
+		}
+		if localPath, ok := f.replace[c.Name]; ok {
+			p.override = localPath
+		}
+		hooks, err := mergeHooks(f.Hooks, c.Hooks)
+		if err != nil {
+			return nil, fmt.Errorf("chart %s: %v", c.Name, err)
+		}
+		p.effectiveHooks = hooks
+		pkgs = append(pkgs, p)
 	}
 
 	return pkgs, nil
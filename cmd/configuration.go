@@ -0,0 +1,227 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	schemaKind        string
+	schemaProvisioner string
+	schemaAPIVersion  string
+
+	migrateConfigFilePath string
+	migrateDryRun         bool
+
+	viewConfigFilePaths    []string
+	viewResolve            bool
+	viewDefaulted          bool
+	viewAllowUnknownFields bool
+
+	validateFormat             string
+	validateAllowUnknownFields bool
+
+	configCmd = &cobra.Command{
+		Use:   "config",
+		Short: "Inspect the cluster and bootstrap configuration files",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			return cmd.Help()
+		},
+	}
+	configSchemaCmd = &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of a configuration file",
+		Long: `Print the JSON Schema of the configuration file of a kind and provisioner.
+
+Editors using the yaml-language-server can use it for validation and autocompletion adding to the configuration file:
+
+  # yaml-language-server: $schema=./cluster-eks.schema.json`,
+		Example: "  furyctl config schema --kind Cluster --provisioner eks > cluster-eks.schema.json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			schema, err := configuration.VersionSchema(schemaAPIVersion, schemaKind, schemaProvisioner)
+			if err != nil {
+				return err
+			}
+			enc := json.NewEncoder(os.Stdout)
+			enc.SetIndent("", "  ")
+			return enc.Encode(schema)
+		},
+	}
+	configMigrateCmd = &cobra.Command{
+		Use:   "migrate",
+		Short: "Update a configuration file to the latest apiVersion",
+		Long: `Update a configuration file to the latest apiVersion, keeping its comments.
+
+The files without an apiVersion are read as ` + configuration.APIVersions()[0] + `.`,
+		Example: "  furyctl config migrate --config cluster.yml",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			content, err := ioutil.ReadFile(migrateConfigFilePath)
+			if err != nil {
+				return err
+			}
+			migrated, version, notes, err := configuration.Migrate(content)
+			if err != nil {
+				return fmt.Errorf("%s: %v", migrateConfigFilePath, err)
+			}
+			if migrateDryRun {
+				_, err = os.Stdout.Write(migrated)
+				return err
+			}
+			if version == configuration.APIVersion {
+				logrus.Infof("%s already uses the latest apiVersion %s", migrateConfigFilePath, version)
+				return nil
+			}
+			for _, note := range notes {
+				logrus.Infof("%s: %s", migrateConfigFilePath, note)
+			}
+			if err = ioutil.WriteFile(migrateConfigFilePath, migrated, 0644); err != nil {
+				return err
+			}
+			logrus.Infof("%s migrated from %s to %s", migrateConfigFilePath, version, configuration.APIVersion)
+			return nil
+		},
+	}
+	configViewCmd = &cobra.Command{
+		Use:   "view",
+		Short: "Print the effective configuration merging the overlays into the configuration file",
+		Long: `Print the effective configuration merging the overlays into the configuration file, converted to the latest apiVersion.
+
+The overlays are merged in order: maps are merged key by key, lists of objects with a name are merged by name,
+a null value removes the key and any other value is replaced.
+
+With --defaulted the missing fields are set to their defaults, marked by a # default comment, to review every value applied.`,
+		Example: `  furyctl config view --config cluster.yml --config production.yml
+  furyctl config view --config cluster.yml --defaulted`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			if len(viewConfigFilePaths) == 0 {
+				return fmt.Errorf("missing the configuration file")
+			}
+			view := configuration.View
+			if viewDefaulted {
+				view = configuration.ViewDefaulted
+			}
+			r, source, err := configuration.Open(viewConfigFilePaths[0])
+			if err != nil {
+				return err
+			}
+			defer r.Close()
+			content, err := view(r, source, configuration.Options{Overlays: viewConfigFilePaths[1:], AllowUnknownFields: viewAllowUnknownFields}, viewResolve)
+			if err != nil {
+				return err
+			}
+			_, err = os.Stdout.Write(content)
+			return err
+		},
+	}
+	configExplainCmd = &cobra.Command{
+		Use:   "explain <kind> <provisioner> [field]",
+		Short: "Describe a field of a configuration file",
+		Long: `Describe a field of the configuration file of a kind and provisioner: its type, whether it is required, its default,
+its accepted values, the terraform variable it sets and its child fields.
+
+The field is a dotted path, the whole configuration file is described when it is omitted.`,
+		Example: `  furyctl config explain cluster eks spec.nodePools.maxPods
+  furyctl config explain bootstrap aws spec.vpn`,
+		Args: cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			path := ""
+			if len(args) == 3 {
+				path = args[2]
+			}
+			explanation, err := configuration.Explain(args[0], args[1], path)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(os.Stdout, explanation)
+			return err
+		},
+	}
+	configValidateCmd = &cobra.Command{
+		Use:   "validate [configuration files]",
+		Short: "Validate configuration files without creating a project",
+		Long: `Validate configuration files against their schema, their network ranges and every other check run by init and apply,
+without creating a project or downloading terraform. Every file is validated on its own, cluster.yml when none is given.
+
+The report is printed as text, json, junit or sarif, the format of the code scanning annotations.
+The command fails when any file is not valid.`,
+		Example: `  furyctl config validate cluster.yml bootstrap.yml
+  furyctl config validate cluster.yml --format sarif > furyctl.sarif`,
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			write, ok := validationFormats[validateFormat]
+			if !ok {
+				return fmt.Errorf("unknown format %s. Choose one of: text, json, junit, sarif", validateFormat)
+			}
+			if len(args) == 0 {
+				args = []string{"cluster.yml"}
+			}
+			stdin := 0
+			for _, arg := range args {
+				if arg == configuration.Stdin {
+					stdin++
+				}
+			}
+			if stdin > 1 {
+				return fmt.Errorf("the standard input can be validated once")
+			}
+			results := validateFiles(args, validateAllowUnknownFields)
+			if err = write(os.Stdout, results); err != nil {
+				return err
+			}
+			invalid := 0
+			for _, r := range results {
+				if !r.Valid {
+					invalid++
+				}
+			}
+			if invalid > 0 {
+				// the report already describes the errors
+				cmd.SilenceUsage = true
+				return fmt.Errorf("%d of %d configuration files are not valid", invalid, len(results))
+			}
+			return nil
+		},
+	}
+)
+
+func init() {
+	configSchemaCmd.Flags().StringVar(&schemaKind, "kind", "", "Kind of the configuration: Cluster or Bootstrap")
+	configSchemaCmd.Flags().StringVar(&schemaProvisioner, "provisioner", "", "Provisioner of the configuration")
+	configSchemaCmd.Flags().StringVar(&schemaAPIVersion, "api-version", configuration.APIVersion, "apiVersion of the configuration")
+	for _, flag := range []string{"kind", "provisioner"} {
+		if err := configSchemaCmd.MarkFlagRequired(flag); err != nil {
+			logrus.Print(err)
+		}
+	}
+
+	configMigrateCmd.Flags().StringVarP(&migrateConfigFilePath, "config", "c", "cluster.yml", "Configuration file path")
+	configMigrateCmd.Flags().BoolVar(&migrateDryRun, "dry-run", false, "Print the migrated configuration file without writing it")
+
+	configViewCmd.Flags().StringArrayVarP(&viewConfigFilePaths, "config", "c", []string{"cluster.yml"}, "Configuration file path, - for the standard input or a go-getter URL. Repeat it to merge overlays into the first file")
+	configViewCmd.Flags().BoolVar(&viewAllowUnknownFields, "allow-unknown-fields", false, "Warn about the unknown fields of the configuration files instead of failing, with --resolve")
+	configViewCmd.Flags().BoolVar(&viewResolve, "resolve", false, "Resolve the environment variables, files and secrets referenced by the values. The secrets are printed in clear text")
+	configViewCmd.Flags().BoolVar(&viewDefaulted, "defaulted", false, "Set the missing fields to their defaults, marked by a # default comment")
+
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configValidateCmd.Flags().StringVar(&validateFormat, "format", "text", "Format of the report: text, json, junit or sarif")
+	configValidateCmd.Flags().BoolVar(&validateAllowUnknownFields, "allow-unknown-fields", false, "Report the unknown fields of the configuration files as warnings instead of errors")
+
+	configCmd.AddCommand(configViewCmd)
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configExplainCmd)
+	rootCmd.AddCommand(configCmd)
+}
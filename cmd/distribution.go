@@ -0,0 +1,77 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var distributionOutput string
+
+func init() {
+	rootCmd.AddCommand(distributionCmd)
+	distributionCmd.AddCommand(distributionListCmd)
+	distributionCmd.PersistentFlags().StringVar(&releaseIndex, "index", defaultReleaseIndex, "URL of the fury-distribution release index")
+	distributionListCmd.Flags().StringVarP(&distributionOutput, "output", "o", planOutputTable, "Output format: table or json")
+}
+
+// distributionCmd represents the distribution command
+var distributionCmd = &cobra.Command{
+	Use:   "distribution",
+	Short: "Discover the Kubernetes Fury Distribution releases",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		return cmd.Help()
+	},
+}
+
+// distributionListCmd represents the distribution list command
+var distributionListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the available distribution releases",
+	Long:  "List the available fury-distribution releases with the Kubernetes versions they support and the versions of their modules",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		index, err := fetchReleaseIndex(releaseIndex)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		index.fetchDetails()
+		err = printReleases(cmd.OutOrStdout(), index.Releases, distributionOutput)
+		if err != nil {
+			logrus.Fatal(err)
+		}
+	},
+}
+
+// printReleases writes the releases in the requested format
+func printReleases(w io.Writer, releases []Release, format string) error {
+	switch format {
+	case planOutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(releases)
+	case planOutputTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tKUBERNETES\tDATE\tMODULES")
+		for _, r := range releases {
+			modules := make([]string, 0, len(r.Modules))
+			for _, name := range r.moduleNames() {
+				modules = append(modules, name+"@"+r.Modules[name])
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Version, strings.Join(r.Kubernetes, ", "), r.Date, strings.Join(modules, " "))
+		}
+		return tw.Flush()
+	default:
+		return fmt.Errorf("unknown output format %s. Choose one of: %s, %s", format, planOutputTable, planOutputJSON)
+	}
+}
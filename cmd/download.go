@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"sort"
 	"strings"
 	"sync"
 
@@ -20,7 +21,7 @@ var parallel bool
 var https bool
 var prefix string
 
-func download(packages []Package) error {
+func download(packages []Package) ([]vendorResult, error) {
 
 	// Preparing all the necessary data for a worker pool
 	var wg sync.WaitGroup
@@ -30,7 +31,7 @@ func download(packages []Package) error {
 	} else {
 		numberOfWorkers = 1
 	}
-	errChan := make(chan error, len(packages))
+	resChan := make(chan vendorResult, len(packages))
 	jobs := make(chan Package, len(packages))
 	logrus.Debugf("workers = %d", numberOfWorkers)
 
@@ -45,8 +46,23 @@ func download(packages []Package) error {
 		go func(i int) {
 			for data := range jobs {
 				logrus.Debugf("%d : received data %v", i, data)
-				res := get(data.url, data.dir, getter.ClientModeDir, true)
-				errChan <- res
+				var res error
+				if data.override != "" {
+					res = useOverride(data.override, data.dir, copyOverrides)
+				} else if data.chart != nil {
+					res = getChart(*data.chart, data.resolvedVersion, data.dir)
+				} else {
+					res = get(data.url, data.dir, getter.ClientModeDir, true)
+				}
+				result := vendorResult{Name: data.Name, Kind: data.kind, Version: data.resolvedVersion, Dir: data.dir, Override: data.override}
+				if res == nil {
+					result.Hooks, res = runPostDownloadHooks(data)
+				}
+				if res != nil {
+					result.err = res
+					result.Error = res.Error()
+				}
+				resChan <- result
 				logrus.Debugf("%d : finished with data %v", i, data)
 			}
 			logrus.Debugf("%d : CLOSING", i)
@@ -58,16 +74,19 @@ func download(packages []Package) error {
 	close(jobs)
 	logrus.Debugf("closed jobs")
 	wg.Wait()
-	close(errChan)
+	close(resChan)
 	logrus.Debugf("finished")
-	for err := range errChan {
-		if err != nil {
+	results := make([]vendorResult, 0, len(packages))
+	for result := range resChan {
+		results = append(results, result)
+		if result.err != nil {
 			//todo ISSUE: logrus doesn't escape string characters
-			errString := strings.Replace(err.Error(), "\n", " ", -1)
+			errString := strings.Replace(result.err.Error(), "\n", " ", -1)
 			logrus.Errorln(errString)
 		}
 	}
-	return nil
+	sort.Slice(results, func(i, j int) bool { return results[i].Dir < results[j].Dir })
+	return results, nil
 }
 
 func get(src, dest string, mode getter.ClientMode, cleanGitFolder bool) error {
@@ -0,0 +1,148 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sighupio/furyctl/internal/shell"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultHookTimeout = 5 * time.Minute
+	vendorReportFile   = ".furyctl-vendor-report.json"
+)
+
+var noHooks bool
+var reportFile string
+
+// Hooks contains the commands to run on a package after some vendor phases
+type Hooks struct {
+	PostDownload []string `yaml:"postDownload"`
+	Timeout      string   `yaml:"timeout"`
+}
+
+// timeout returns the hook timeout, falling back to the given default
+func (h Hooks) timeout(fallback time.Duration) (time.Duration, error) {
+	if h.Timeout == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(h.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid hooks timeout %s: %v", h.Timeout, err)
+	}
+	return d, nil
+}
+
+// mergeHooks returns the global hooks followed by the package ones
+func mergeHooks(global, pkg Hooks) (Hooks, error) {
+	globalTimeout, err := global.timeout(defaultHookTimeout)
+	if err != nil {
+		return Hooks{}, err
+	}
+	timeout, err := pkg.timeout(globalTimeout)
+	if err != nil {
+		return Hooks{}, err
+	}
+	merged := Hooks{Timeout: timeout.String()}
+	merged.PostDownload = append(merged.PostDownload, global.PostDownload...)
+	merged.PostDownload = append(merged.PostDownload, pkg.PostDownload...)
+	return merged, nil
+}
+
+// hookResult is the outcome of a single hook execution
+type hookResult struct {
+	Command  string `json:"command"`
+	Output   string `json:"output"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+// vendorResult is the outcome of vendoring a single package
+type vendorResult struct {
+	Name     string       `json:"name"`
+	Kind     string       `json:"kind"`
+	Version  string       `json:"version"`
+	Dir      string       `json:"dir"`
+	Override string       `json:"override,omitempty"`
+	Error    string       `json:"error,omitempty"`
+	Hooks    []hookResult `json:"hooks,omitempty"`
+	err      error
+}
+
+// runPostDownloadHooks runs the post download hooks of a package inside its directory, stopping at the first failure
+func runPostDownloadHooks(p Package) ([]hookResult, error) {
+	if noHooks || len(p.effectiveHooks.PostDownload) == 0 {
+		return nil, nil
+	}
+	if p.override != "" && !copyOverrides {
+		logrus.Warnf("skipping post download hooks of %s: it is a symlink to %s", p.Name, p.override)
+		return nil, nil
+	}
+	timeout, err := p.effectiveHooks.timeout(defaultHookTimeout)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := filepath.Abs(p.dir)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]hookResult, 0, len(p.effectiveHooks.PostDownload))
+	for _, command := range p.effectiveHooks.PostDownload {
+		logrus.Infof("running post download hook of %s: %s", p.Name, command)
+		result, err := runHook(command, dir, timeout, hookEnv(p, dir))
+		results = append(results, result)
+		if err != nil {
+			return results, fmt.Errorf("post download hook of %s failed: %s: %v", p.Name, command, err)
+		}
+		logrus.Debugf("%s: %s", command, result.Output)
+	}
+	return results, nil
+}
+
+func hookEnv(p Package, dir string) []string {
+	return append(os.Environ(),
+		"FURYCTL_PACKAGE_NAME="+p.Name,
+		"FURYCTL_PACKAGE_KIND="+p.kind,
+		"FURYCTL_PACKAGE_VERSION="+p.resolvedVersion,
+		"FURYCTL_PACKAGE_DIR="+dir,
+	)
+}
+
+// runHook runs a shell command capturing its combined output
+func runHook(command, dir string, timeout time.Duration, env []string) (hookResult, error) {
+	var output bytes.Buffer
+	start := time.Now()
+	err := shell.Run(command, dir, timeout, env, &output)
+	result := hookResult{
+		Command:  command,
+		Output:   strings.TrimSpace(output.String()),
+		Duration: time.Since(start).Round(time.Millisecond).String(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result, err
+}
+
+// writeReport stores the vendor results as json, creating the vendor folder when every download failed
+func writeReport(path string, results []vendorResult) error {
+	content, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeHooks(t *testing.T) {
+	global := Hooks{PostDownload: []string{"global"}, Timeout: "1m"}
+
+	merged, err := mergeHooks(global, Hooks{PostDownload: []string{"pkg"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(merged.PostDownload, []string{"global", "pkg"}) {
+		t.Errorf("postDownload = %v, want the global hooks first", merged.PostDownload)
+	}
+	if merged.Timeout != "1m0s" {
+		t.Errorf("timeout = %s, want the global one", merged.Timeout)
+	}
+
+	merged, err = mergeHooks(global, Hooks{Timeout: "10s"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Timeout != "10s" {
+		t.Errorf("timeout = %s, want the package one", merged.Timeout)
+	}
+
+	if _, err = mergeHooks(Hooks{Timeout: "soon"}, Hooks{}); err == nil {
+		t.Error("mergeHooks() should fail on an invalid timeout")
+	}
+}
+
+func TestRunHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-hooks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	result, err := runHook("echo $FOO; pwd", dir, time.Minute, []string{"FOO=bar"})
+	if err != nil {
+		t.Fatalf("runHook() error = %v", err)
+	}
+	if !strings.HasPrefix(result.Output, "bar\n") || !strings.HasSuffix(result.Output, filepath.Base(dir)) {
+		t.Errorf("unexpected output %q", result.Output)
+	}
+
+	start := time.Now()
+	result, err = runHook("sleep 10 & wait", dir, 100*time.Millisecond, nil)
+	if err == nil || !strings.Contains(result.Error, "timed out") {
+		t.Fatalf("runHook() error = %v, want a timeout", err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Errorf("runHook() did not kill the hook children on timeout")
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-report")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the vendor folder is missing when every download failed
+	path := filepath.Join(dir, "vendor", vendorReportFile)
+	if err = writeReport(path, []vendorResult{{Name: "networking", Kind: "katalog", Error: "download failed"}}); err != nil {
+		t.Fatalf("writeReport() error = %v", err)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil || !strings.Contains(string(content), `"error": "download failed"`) {
+		t.Errorf("writeReport() report = %s, %v", content, err)
+	}
+}
+
+func TestParseInvalidHooks(t *testing.T) {
+	f := Furyconf{
+		VendorFolderName: "vendor",
+		Bases:            []Package{{Name: "networking", Version: "v1.8.0", Hooks: Hooks{Timeout: "soon"}}},
+	}
+	list, err := f.Parse("")
+	if err == nil || !strings.Contains(err.Error(), "package networking") {
+		t.Errorf("Parse() error = %v, want the invalid timeout of the package", err)
+	}
+	if list != nil {
+		t.Errorf("Parse() = %v, want no packages", list)
+	}
+}
@@ -5,6 +5,14 @@
 package cmd
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sighupio/furyctl/internal/configuration"
 	"github.com/sirupsen/logrus"
 
 	getter "github.com/hashicorp/go-getter"
@@ -12,17 +20,40 @@ import (
 )
 
 const (
-	furyFile                    = "Furyfile.yml"
-	kustomizationFile           = "kustomization.yaml"
-	httpsDistributionRepoPrefix = "http::https://github.com/sighupio/fury-distribution/releases/download/"
+	furyFile          = "Furyfile.yml"
+	kustomizationFile = "kustomization.yaml"
+	bootstrapFile     = "bootstrap.yml"
+	clusterFile       = "cluster.yml"
+	gitattributesFile = ".gitattributes"
+	checksumsFile     = "checksums.txt"
 )
 
 var fileNames = [...]string{furyFile, kustomizationFile}
 var distributionVersion string
+var initInteractive bool
+var initOpts initOptions
+var initForce bool
+var skipChecksums bool
+var skipIndex bool
+
+// projectGitignore are the paths of a distribution project that must never be committed
+var projectGitignore = []string{defaultVendorFolderName + "/", "bootstrap/", "cluster/", defaultOverrideFile}
+
+// projectGitattributes marks the vendored packages as generated code
+var projectGitattributes = []string{defaultVendorFolderName + "/** linguist-vendored -diff"}
 
 func init() {
 	rootCmd.AddCommand(initCmd)
 	initCmd.Flags().StringVar(&distributionVersion, "version", "", "Specify the Kubernetes Fury Distribution version")
+	initCmd.Flags().StringVar(&releaseIndex, "index", defaultReleaseIndex, "URL of the fury-distribution release index the version is validated against")
+	initCmd.Flags().BoolVar(&skipIndex, "skip-index", false, "if true downloads the release without validating the version against the release index")
+	initCmd.Flags().BoolVarP(&initInteractive, "interactive", "i", false, "if true asks which modules, provider and configurations the project needs")
+	initCmd.Flags().StringSliceVar(&initOpts.modules, "modules", nil, "Modules of the distribution to add to the project, e.g. networking,monitoring. Defaults to all of them")
+	initCmd.Flags().StringVar(&initOpts.provider, "provider", "", "Cloud provider of the project: aws, gcp or vsphere")
+	initCmd.Flags().BoolVar(&initOpts.bootstrap, "bootstrap", false, "if true generates a bootstrap.yml for the provider")
+	initCmd.Flags().BoolVar(&initOpts.cluster, "cluster", false, "if true generates a cluster.yml for the provider")
+	initCmd.Flags().BoolVar(&initForce, "force", false, "if true overwrites the existing project files")
+	initCmd.Flags().BoolVar(&skipChecksums, "skip-checksums", false, "if true does not verify the release files against the release "+checksumsFile)
 	err := initCmd.MarkFlagRequired("version")
 	if err != nil {
 		logrus.Print(err)
@@ -32,19 +63,251 @@ func init() {
 var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize the minimum distribution configuration",
-	Long:  "Initialize the current directory with the minimum distribution configuration",
+	Long: `Initialize the current directory with the minimum distribution configuration.
+
+By default every module of the distribution release is added to the Furyfile.yml and kustomization.yaml files.
+Use --modules to pick only some of them and --provider with --bootstrap and/or --cluster to generate the
+bootstrap.yml and cluster.yml configuration templates, or run with --interactive to be asked for each choice.`,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		for _, fileName := range fileNames {
-			url := httpsDistributionRepoPrefix + distributionVersion + "/" + fileName
-			err = downloadFile(url, fileName)
+		if err = initOpts.validate(); err != nil {
+			return err
+		}
+
+		release, err := findRelease(releaseIndex, distributionVersion)
+		if err != nil {
+			return err
+		}
+		if len(release.Kubernetes) > 0 {
+			logrus.Infof("initializing the project with the distribution %s, compatible with Kubernetes %s", release.Version, strings.Join(release.Kubernetes, ", "))
+		} else {
+			logrus.Infof("initializing the project with the distribution %s", release.Version)
+		}
+
+		tmp, err := ioutil.TempDir("", "furyctl-init")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+
+		releaseFiles, err := downloadRelease(release, tmp)
+		if err != nil {
+			return err
+		}
+
+		if initInteractive {
+			modules, err := availableModules(releaseFiles[furyFile])
+			if err != nil {
+				return err
+			}
+			initOpts, err = newWizard(os.Stdin, os.Stdout).run(modules, initOpts)
 			if err != nil {
 				return err
 			}
 		}
-		return nil
+
+		files, err := initOpts.render(releaseFiles)
+		if err != nil {
+			return err
+		}
+		if err = writeProjectFiles(files, initForce); err != nil {
+			return err
+		}
+
+		if err = writeDistributionState(distributionState{Version: release.Version, Modules: initOpts.modules}); err != nil {
+			return err
+		}
+
+		if err = ensureGitignored(projectGitignore...); err != nil {
+			return err
+		}
+		return ensureLines(gitattributesFile, projectGitattributes...)
 	},
 }
 
+// findRelease looks for the version in the release index, filling the details the index doesn't list.
+// With --skip-index the release files are downloaded straight from the fury-distribution release assets
+func findRelease(indexURL, version string) (Release, error) {
+	if skipIndex {
+		logrus.Warnf("downloading the release %s without checking it against the release index", version)
+		return Release{Version: version}, nil
+	}
+	index, err := fetchReleaseIndex(indexURL)
+	if err != nil {
+		return Release{}, fmt.Errorf("%v. Use --skip-index to download the release without checking it", err)
+	}
+	release, err := index.find(version)
+	if err != nil {
+		return Release{}, fmt.Errorf("%v. Run furyctl distribution list to see the releases", err)
+	}
+	if err = release.fetchDetails(); err != nil {
+		logrus.Warnf("unable to read the Kubernetes versions and the modules of the release %s: %v", release.Version, err)
+	}
+	return release, nil
+}
+
+// projectFile is a file generated by init
+type projectFile struct {
+	name    string
+	content []byte
+}
+
+// provisioners are the bootstrap and cluster provisioners used for each cloud provider
+var provisioners = map[string]struct{ bootstrap, cluster string }{
+	"aws":     {bootstrap: "aws", cluster: "eks"},
+	"gcp":     {bootstrap: "gcp", cluster: "gke"},
+	"vsphere": {cluster: "vsphere"},
+}
+
+// initOptions are the choices, given with flags or answered to the wizard, used to tailor the project
+type initOptions struct {
+	modules   []string
+	provider  string
+	bootstrap bool
+	cluster   bool
+}
+
+func (o initOptions) validate() error {
+	if o.provider == "" {
+		if o.bootstrap || o.cluster {
+			return fmt.Errorf("a --provider is required to generate the bootstrap and cluster configurations")
+		}
+		return nil
+	}
+	p, ok := provisioners[o.provider]
+	if !ok {
+		return fmt.Errorf("unknown provider %s. Choose one of aws, gcp or vsphere", o.provider)
+	}
+	if o.bootstrap && p.bootstrap == "" {
+		return fmt.Errorf("the %s provider has no bootstrap provisioner", o.provider)
+	}
+	return nil
+}
+
+// render generates the project files starting from the release Furyfile.yml and kustomization.yaml
+func (o initOptions) render(release map[string][]byte) ([]projectFile, error) {
+	available, err := availableModules(release[furyFile])
+	if err != nil {
+		return nil, err
+	}
+	if _, unknown := splitChoices(strings.Join(o.modules, ","), available); len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown modules %s. The release %s provides: %s", strings.Join(unknown, ", "), distributionVersion, strings.Join(available, ", "))
+	}
+	filtered, err := filterRelease(release, o.modules)
+	if err != nil {
+		return nil, err
+	}
+	files := []projectFile{{furyFile, filtered[furyFile]}, {kustomizationFile, filtered[kustomizationFile]}}
+
+	p := provisioners[o.provider]
+	if o.bootstrap {
+		tpl, err := configuration.Template("Bootstrap", p.bootstrap)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, projectFile{bootstrapFile, []byte(tpl)})
+	}
+	if o.cluster {
+		tpl, err := configuration.Template("Cluster", p.cluster)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, projectFile{clusterFile, []byte(tpl)})
+	}
+	return files, nil
+}
+
+// downloadRelease downloads the Furyfile.yml and kustomization.yaml of a release into dir returning their content,
+// verifying them against the release checksums file. A release without it, or without the checksum of a file,
+// fails unless --skip-checksums is given
+func downloadRelease(release Release, dir string) (map[string][]byte, error) {
+	var checksums map[string]string
+	if skipChecksums {
+		logrus.Warnf("skipping the checksum verification of the %s release files", release.Version)
+	} else {
+		content, err := httpGet(release.assetsURL()+checksumsFile, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to download the %s of the release %s: %v. Use --skip-checksums to skip the verification", checksumsFile, release.Version, err)
+		}
+		checksums = parseChecksums(content)
+	}
+
+	files := map[string][]byte{}
+	for _, fileName := range fileNames {
+		url := "http::" + release.assetsURL() + fileName
+		dest := filepath.Join(dir, fileName)
+		err := downloadFile(url, dest)
+		if err != nil {
+			return nil, err
+		}
+		files[fileName], err = ioutil.ReadFile(dest)
+		if err != nil {
+			return nil, err
+		}
+		if checksums == nil {
+			continue
+		}
+		sum, ok := checksums[fileName]
+		if !ok {
+			return nil, fmt.Errorf("%s of the release %s has no checksum in %s. Use --skip-checksums to skip the verification", fileName, release.Version, checksumsFile)
+		}
+		if err = verifyDigest(files[fileName], "sha256:"+sum); err != nil {
+			return nil, fmt.Errorf("%s of the release %s: %v", fileName, release.Version, err)
+		}
+	}
+	return files, nil
+}
+
+// parseChecksums reads a sha256sum formatted file returning the checksums by file name
+func parseChecksums(content []byte) map[string]string {
+	checksums := map[string]string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		checksums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return checksums
+}
+
+// writeProjectFiles writes the generated files reporting the ones created or changed.
+// Existing files with a different content are overwritten only when forced
+func writeProjectFiles(files []projectFile, force bool) error {
+	var created, changed, unchanged []string
+	for _, f := range files {
+		current, err := ioutil.ReadFile(f.name)
+		switch {
+		case os.IsNotExist(err):
+			created = append(created, f.name)
+		case err != nil:
+			return err
+		case bytes.Equal(current, f.content):
+			unchanged = append(unchanged, f.name)
+		default:
+			changed = append(changed, f.name)
+		}
+	}
+	if len(changed) > 0 && !force {
+		return fmt.Errorf("refusing to overwrite %s: the files already exist with a different content. Use --force to overwrite them", strings.Join(changed, ", "))
+	}
+
+	for _, f := range files {
+		if err := ioutil.WriteFile(f.name, f.content, 0644); err != nil {
+			return err
+		}
+	}
+	for _, name := range created {
+		logrus.Infof("created %s", name)
+	}
+	for _, name := range changed {
+		logrus.Warnf("overwritten %s", name)
+	}
+	for _, name := range unchanged {
+		logrus.Infof("unchanged %s", name)
+	}
+	return nil
+}
+
 func downloadFile(url string, outputFileName string) error {
 	err := get(url, outputFileName, getter.ClientModeFile, false)
 	if err != nil {
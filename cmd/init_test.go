@@ -0,0 +1,105 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const releaseFuryfile = `versions:
+  networking: v1.8.2
+  monitoring: v1.14.1
+  logging: v1.10.2
+
+bases:
+  - name: networking/
+  - name: monitoring/
+  - name: logging/
+
+modules:
+  - name: monitoring/eks-servicemonitors
+`
+
+const releaseKustomization = `apiVersion: kustomize.config.k8s.io/v1beta1
+kind: Kustomization
+resources:
+  - ./vendor/katalog/networking/calico
+  - ./vendor/katalog/monitoring/prometheus-operator
+  - ./vendor/katalog/logging/elasticsearch-single
+  - ./resources/custom.yml
+`
+
+func TestAvailableModules(t *testing.T) {
+	modules, err := availableModules([]byte(releaseFuryfile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"networking", "monitoring", "logging"}; !reflect.DeepEqual(modules, want) {
+		t.Errorf("availableModules() = %v, want %v", modules, want)
+	}
+}
+
+func TestRenderProject(t *testing.T) {
+	release := map[string][]byte{furyFile: []byte(releaseFuryfile), kustomizationFile: []byte(releaseKustomization)}
+	o := initOptions{modules: []string{"monitoring"}, provider: "aws", cluster: true}
+	files, err := o.render(release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 3 || files[2].name != clusterFile {
+		t.Fatalf("render() files = %v, want the Furyfile, the kustomization and the cluster.yml", files)
+	}
+
+	furyfile := string(files[0].content)
+	for _, want := range []string{"monitoring: v1.14.1", "name: monitoring/", "name: monitoring/eks-servicemonitors"} {
+		if !strings.Contains(furyfile, want) {
+			t.Errorf("Furyfile misses %q:\n%s", want, furyfile)
+		}
+	}
+	if strings.Contains(furyfile, "networking") || strings.Contains(furyfile, "logging") {
+		t.Errorf("Furyfile contains unselected modules:\n%s", furyfile)
+	}
+
+	kustomization := string(files[1].content)
+	if !strings.Contains(kustomization, "prometheus-operator") || !strings.Contains(kustomization, "./resources/custom.yml") {
+		t.Errorf("kustomization misses the selected resources:\n%s", kustomization)
+	}
+	if strings.Contains(kustomization, "calico") {
+		t.Errorf("kustomization contains unselected resources:\n%s", kustomization)
+	}
+
+	if !strings.Contains(string(files[2].content), "provisioner: eks") {
+		t.Errorf("cluster.yml is not an eks template:\n%s", files[2].content)
+	}
+
+	o.modules = []string{"dr"}
+	if _, err = o.render(release); err == nil || !strings.Contains(err.Error(), "unknown modules dr") {
+		t.Errorf("render() error = %v, want an unknown module error", err)
+	}
+}
+
+func TestWizard(t *testing.T) {
+	input := strings.NewReader("networking, foo\nnetworking,logging\ngcp\n\nmaybe\nn\n")
+	o, err := newWizard(input, ioutil.Discard).run([]string{"networking", "monitoring", "logging"}, initOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := initOptions{modules: []string{"networking", "logging"}, provider: "gcp", bootstrap: true}
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("wizard answers = %+v, want %+v", o, want)
+	}
+
+	o, err = newWizard(strings.NewReader(""), ioutil.Discard).run([]string{"networking"}, initOptions{provider: "vsphere", cluster: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = initOptions{modules: []string{"networking"}, provider: "vsphere", cluster: true}
+	if !reflect.DeepEqual(o, want) {
+		t.Errorf("wizard defaults = %+v, want %+v", o, want)
+	}
+}
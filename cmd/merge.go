@@ -0,0 +1,262 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// mergeConflict is a value changed both locally and upstream in different ways
+type mergeConflict struct {
+	Path     string      `json:"path"`
+	Base     interface{} `json:"base"`
+	Local    interface{} `json:"local"`
+	Upstream interface{} `json:"upstream"`
+}
+
+// merge3 merges the local and the upstream changes made to a yaml document starting from the same base.
+// Maps are merged key by key, lists of objects with a name are merged by name and lists of values as sets.
+// The merged document keeps the layout and the comments of the local one, the values coming from upstream keep
+// their own comments. When both sides changed the same value the local one is kept and a conflict is reported.
+// A nil node marks a key or an item missing from one of the documents
+func merge3(base, local, upstream []byte) ([]byte, []mergeConflict, error) {
+	var b, l, u yamlv3.Node
+	for _, doc := range []struct {
+		content []byte
+		into    *yamlv3.Node
+	}{{base, &b}, {local, &l}, {upstream, &u}} {
+		if err := yamlv3.Unmarshal(doc.content, doc.into); err != nil {
+			return nil, nil, err
+		}
+	}
+	var conflicts []mergeConflict
+	merged := mergeValues("", documentRoot(&b), documentRoot(&l), documentRoot(&u), &conflicts)
+	if merged == nil {
+		return []byte{}, conflicts, nil
+	}
+	doc := l
+	if doc.Kind != yamlv3.DocumentNode {
+		doc = yamlv3.Node{Kind: yamlv3.DocumentNode}
+	}
+	doc.Content = []*yamlv3.Node{merged}
+
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), conflicts, nil
+}
+
+// documentRoot returns the root value of a document, nil for an empty one
+func documentRoot(doc *yamlv3.Node) *yamlv3.Node {
+	if doc.Kind != yamlv3.DocumentNode || len(doc.Content) == 0 {
+		return nil
+	}
+	return doc.Content[0]
+}
+
+func mergeValues(path string, base, local, upstream *yamlv3.Node, conflicts *[]mergeConflict) *yamlv3.Node {
+	if equalNodes(local, upstream) {
+		return local
+	}
+	// the maps and the lists are merged even when one side is unchanged, to keep the local comments
+	if local != nil && upstream != nil && local.Kind == upstream.Kind {
+		switch local.Kind {
+		case yamlv3.MappingNode:
+			return mergeMaps(path, base, local, upstream, conflicts)
+		case yamlv3.SequenceNode:
+			return mergeLists(path, base, local, upstream, conflicts)
+		}
+	}
+	switch {
+	case equalNodes(base, local):
+		return keepComments(upstream, local)
+	case equalNodes(base, upstream):
+		return local
+	}
+
+	*conflicts = append(*conflicts, mergeConflict{Path: path, Base: decodeNode(base), Local: decodeNode(local), Upstream: decodeNode(upstream)})
+	return local
+}
+
+func mergeMaps(path string, base, local, upstream *yamlv3.Node, conflicts *[]mergeConflict) *yamlv3.Node {
+	merged := *local
+	merged.Content = nil
+	for _, key := range orderedKeys(local, upstream) {
+		v := mergeValues(joinPath(path, key.Value), lookup(base, key.Value), lookup(local, key.Value), lookup(upstream, key.Value), conflicts)
+		if v != nil {
+			merged.Content = append(merged.Content, key, v)
+		}
+	}
+	return &merged
+}
+
+func mergeLists(path string, base, local, upstream *yamlv3.Node, conflicts *[]mergeConflict) *yamlv3.Node {
+	var baseItems []*yamlv3.Node
+	if base != nil && base.Kind == yamlv3.SequenceNode {
+		baseItems = base.Content
+	}
+	merged := *local
+	merged.Content = nil
+	if named(baseItems) && named(local.Content) && named(upstream.Content) {
+		for _, n := range itemNames(local.Content, upstream.Content) {
+			v := mergeValues(fmt.Sprintf("%s[%s]", path, n), findNamed(baseItems, n), findNamed(local.Content, n), findNamed(upstream.Content, n), conflicts)
+			if v != nil {
+				merged.Content = append(merged.Content, v)
+			}
+		}
+		return &merged
+	}
+	for _, item := range local.Content {
+		if contains(baseItems, item) && !contains(upstream.Content, item) {
+			continue
+		}
+		merged.Content = append(merged.Content, item)
+	}
+	for _, item := range upstream.Content {
+		if !contains(baseItems, item) && !contains(local.Content, item) {
+			merged.Content = append(merged.Content, item)
+		}
+	}
+	return &merged
+}
+
+// keepComments returns the upstream value with the local comments, unless it has its own
+func keepComments(upstream, local *yamlv3.Node) *yamlv3.Node {
+	if upstream == nil || local == nil {
+		return upstream
+	}
+	node := *upstream
+	if node.HeadComment == "" && node.LineComment == "" && node.FootComment == "" {
+		node.HeadComment, node.LineComment, node.FootComment = local.HeadComment, local.LineComment, local.FootComment
+	}
+	return &node
+}
+
+// orderedKeys returns the key nodes of the maps, the local ones first
+func orderedKeys(maps ...*yamlv3.Node) []*yamlv3.Node {
+	keys := []*yamlv3.Node{}
+	seen := map[string]bool{}
+	for _, m := range maps {
+		for i := 0; i+1 < len(m.Content); i += 2 {
+			if k := m.Content[i]; !seen[k.Value] {
+				seen[k.Value] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	return keys
+}
+
+func lookup(m *yamlv3.Node, key string) *yamlv3.Node {
+	if m == nil || m.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// named returns true for the lists made only of objects with a name, like the Furyfile packages
+func named(items []*yamlv3.Node) bool {
+	for _, item := range items {
+		if itemName(item) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func itemName(item *yamlv3.Node) string {
+	if name := lookup(item, "name"); name != nil && name.Kind == yamlv3.ScalarNode {
+		return name.Value
+	}
+	return ""
+}
+
+// itemNames returns the names of the items of the lists, the local ones first
+func itemNames(lists ...[]*yamlv3.Node) []string {
+	names := []string{}
+	seen := map[string]bool{}
+	for _, list := range lists {
+		for _, item := range list {
+			if n := itemName(item); !seen[n] {
+				seen[n] = true
+				names = append(names, n)
+			}
+		}
+	}
+	return names
+}
+
+func findNamed(items []*yamlv3.Node, name string) *yamlv3.Node {
+	for _, item := range items {
+		if itemName(item) == name {
+			return item
+		}
+	}
+	return nil
+}
+
+func contains(items []*yamlv3.Node, item *yamlv3.Node) bool {
+	for _, i := range items {
+		if equalNodes(i, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalNodes compares the values of two nodes ignoring their comments, style and position
+func equalNodes(a, b *yamlv3.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Kind == yamlv3.AliasNode {
+		return equalNodes(a.Alias, b)
+	}
+	if b.Kind == yamlv3.AliasNode {
+		return equalNodes(a, b.Alias)
+	}
+	if a.Kind != b.Kind || a.ShortTag() != b.ShortTag() || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !equalNodes(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeNode returns the value of a node for reporting, nil when missing
+func decodeNode(node *yamlv3.Node) interface{} {
+	if node == nil {
+		return nil
+	}
+	var v interface{}
+	if err := node.Decode(&v); err != nil {
+		return node.Value
+	}
+	return v
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
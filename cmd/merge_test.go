@@ -0,0 +1,122 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge3(t *testing.T) {
+	base := `versions:
+  networking: v1.6.0
+  monitoring: v1.12.0
+  logging: v1.9.0
+bases:
+  - name: networking/
+  - name: monitoring/
+  - name: logging/
+`
+	local := `# project modules
+vendorFolderName: vendor
+versions:
+  networking: v1.6.0
+  monitoring: v1.12.0
+  logging: v1.9.1 # pinned for a hotfix
+bases:
+  - name: networking/
+  - name: monitoring/
+  - name: logging/
+  - name: ingress/nginx
+    version: v1.10.0
+`
+	upstream := `versions:
+  networking: v1.7.0
+  monitoring: v1.13.0
+  logging: v1.10.0
+  # policies
+  opa: v1.5.0
+bases:
+  - name: networking/
+  - name: logging/
+  - name: opa/
+`
+	want := `# project modules
+vendorFolderName: vendor
+versions:
+  networking: v1.7.0
+  monitoring: v1.13.0
+  logging: v1.9.1 # pinned for a hotfix
+  # policies
+  opa: v1.5.0
+bases:
+  - name: networking/
+  - name: logging/
+  - name: ingress/nginx
+    version: v1.10.0
+  - name: opa/
+`
+	merged, conflicts, err := merge3([]byte(base), []byte(local), []byte(upstream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(merged) != want {
+		t.Errorf("merge3() =\n%s\nwant\n%s", merged, want)
+	}
+	if len(conflicts) != 1 || conflicts[0].Path != "versions.logging" || conflicts[0].Local != "v1.9.1" || conflicts[0].Upstream != "v1.10.0" {
+		t.Errorf("conflicts = %+v, want the logging version", conflicts)
+	}
+}
+
+func TestMerge3Lists(t *testing.T) {
+	base := "resources:\n- a\n- b\n- c\n"
+	local := "resources:\n- a\n- b\n- c\n- local\n"
+	upstream := "resources:\n- a\n- c\n- d\n"
+	merged, conflicts, err := merge3([]byte(base), []byte(local), []byte(upstream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "resources:\n  - a\n  - c\n  - local\n  - d\n"; string(merged) != want {
+		t.Errorf("merge3() =\n%s\nwant\n%s", merged, want)
+	}
+	if len(conflicts) != 0 {
+		t.Errorf("conflicts = %+v, want none", conflicts)
+	}
+}
+
+func TestMergeRelease(t *testing.T) {
+	dir, cleanup := inTempDir(t)
+	defer cleanup()
+	base := map[string][]byte{furyFile: []byte("versions:\n  networking: v1.6.0\n"), kustomizationFile: []byte("resources:\n  - a\n")}
+	upstream := map[string][]byte{furyFile: []byte("versions:\n  networking: v1.7.0\n"), kustomizationFile: []byte("resources:\n  - a\n  - b\n")}
+
+	tests := []struct {
+		name     string
+		local    string
+		want     string
+		errorMsg string
+	}{
+		{name: "no conflicts", local: "# local\nversions:\n  networking: v1.6.0\n", want: "# local\nversions:\n  networking: v1.7.0\n"},
+		{name: "conflicts", local: "versions:\n  networking: v1.6.1\n", errorMsg: "1 conflicts between the local and the upstream changes, no file was written"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTestFiles(t, dir, map[string]string{furyFile: tt.local, kustomizationFile: "resources:\n  - a\n"})
+			merged, err := mergeRelease(base, upstream)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("mergeRelease() error = %v, want %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("mergeRelease() error = %v", err)
+			}
+			if string(merged[furyFile]) != tt.want || string(merged[kustomizationFile]) != "resources:\n  - a\n  - b\n" {
+				t.Errorf("mergeRelease() = %s", merged)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultOverrideFile = "Furyfile.override.yml"
+	overrideStateFile   = ".furyctl-overrides.yml"
+	gitignoreFile       = ".gitignore"
+)
+
+var overrideFile string
+var noOverride bool
+var copyOverrides bool
+
+// FuryOverride is reponsible for the structure of the Furyfile.override.yml file:
+//
+//	replace:
+//	  networking/calico: ../fury-kubernetes-networking
+type FuryOverride struct {
+	Replace map[string]string `yaml:"replace"`
+}
+
+// overrideState is the abstraction of the file tracking which packages in the vendor folder are overridden
+type overrideState struct {
+	Packages map[string]string `yaml:"packages"`
+}
+
+// loadOverride reads the override file, returning an empty override when the file does not exist
+func loadOverride(path string) (*FuryOverride, error) {
+	o := &FuryOverride{Replace: map[string]string{}}
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = yaml.Unmarshal(content, o)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s: %v", path, err)
+	}
+	for name, localPath := range o.Replace {
+		abs, err := filepath.Abs(localPath)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := os.Stat(abs); err != nil {
+			return nil, fmt.Errorf("override for package %s points to %s: %v", name, localPath, err)
+		}
+		o.Replace[name] = abs
+	}
+	return o, nil
+}
+
+// guardOverride makes sure the override file and the override state can't end up in the repository
+func guardOverride(path, vendorFolder string) error {
+	state := filepath.Join(vendorFolder, overrideStateFile)
+	for _, f := range []string{path, state} {
+		if isTrackedByGit(f) {
+			return fmt.Errorf("%s is tracked by git. Local overrides must not be committed, remove it with: git rm --cached %s", f, f)
+		}
+	}
+	return ensureGitignored(path, state)
+}
+
+// isTrackedByGit returns true if the file is part of the git index of the current directory
+func isTrackedByGit(path string) bool {
+	if _, err := exec.LookPath("git"); err != nil {
+		return false
+	}
+	return exec.Command("git", "ls-files", "--error-unmatch", path).Run() == nil
+}
+
+// ensureGitignored appends the entries not yet present in the .gitignore file
+func ensureGitignored(entries ...string) error {
+	return ensureLines(gitignoreFile, entries...)
+}
+
+// ensureLines appends the entries not yet present in a line based file like .gitignore or .gitattributes
+func ensureLines(file string, entries ...string) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	present := map[string]bool{}
+	for _, line := range strings.Split(string(content), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+	var missing []string
+	for _, e := range entries {
+		e = filepath.ToSlash(e)
+		if !present[e] && !present["/"+e] {
+			missing = append(missing, e)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	f, err := os.OpenFile(file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		missing[0] = "\n" + missing[0]
+	}
+	logrus.Infof("adding %s to %s", strings.Join(missing, ", "), file)
+	_, err = f.WriteString(strings.Join(missing, "\n") + "\n")
+	return err
+}
+
+// writeOverrideState records the overridden packages of the current vendor run
+func writeOverrideState(vendorFolder string, packages []Package) error {
+	state := overrideState{Packages: map[string]string{}}
+	for _, p := range packages {
+		if p.override != "" {
+			state.Packages[p.dir] = p.override
+		}
+	}
+	path := filepath.Join(vendorFolder, overrideStateFile)
+	if len(state.Packages) == 0 {
+		return removeDir(path)
+	}
+	content, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(vendorFolder, os.ModePerm); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, content, 0644)
+}
+
+// readOverrideState returns the packages overridden by the previous vendor run
+func readOverrideState(vendorFolder string) map[string]string {
+	state := overrideState{}
+	content, err := ioutil.ReadFile(filepath.Join(vendorFolder, overrideStateFile))
+	if err != nil {
+		return nil
+	}
+	if err = yaml.Unmarshal(content, &state); err != nil {
+		logrus.Warnf("unable to read the override state: %v", err)
+		return nil
+	}
+	return state.Packages
+}
+
+// useOverride places the local checkout of a package into its vendor directory
+func useOverride(src, dest string, copyFiles bool) error {
+	logrus.Infof("overriding: %s -> %s [OVERRIDE]", src, dest)
+
+	tempDest := dest + ".tmp"
+	err := removeDir(tempDest)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(dest), os.ModePerm)
+	if err != nil {
+		return err
+	}
+	if copyFiles {
+		err = copyDir(src, tempDest)
+	} else {
+		err = os.Symlink(src, tempDest)
+	}
+	if err != nil {
+		_ = removeDir(tempDest)
+		return err
+	}
+	return renameDir(tempDest, dest)
+}
+
+// copyDir recursively copies a directory skipping the .git folder
+func copyDir(src, dest string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dest, rel)
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, info.Mode())
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		default:
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			return ioutil.WriteFile(target, content, info.Mode())
+		}
+	})
+}
@@ -0,0 +1,264 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// inTempDir runs the test from a new temporary directory, the override files are relative to the current one
+func inTempDir(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "furyctl-override")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// resolve the symlinks of the temporary directory, e.g. /var -> /private/var, to compare the absolute paths
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	return dir, func() {
+		_ = os.Chdir(wd)
+		_ = os.RemoveAll(dir)
+	}
+}
+
+func writeTestFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadOverride(t *testing.T) {
+	dir, cleanup := inTempDir(t)
+	defer cleanup()
+	writeTestFiles(t, dir, map[string]string{"fury-kubernetes-networking/katalog/calico/kustomization.yaml": ""})
+
+	tests := []struct {
+		name     string
+		content  string
+		want     map[string]string
+		errorMsg string
+	}{
+		{name: "missing file", want: map[string]string{}},
+		{
+			name:    "relative path",
+			content: "replace:\n  networking/calico: fury-kubernetes-networking/katalog/calico\n",
+			want:    map[string]string{"networking/calico": filepath.Join(dir, "fury-kubernetes-networking/katalog/calico")},
+		},
+		{
+			name:    "absolute path",
+			content: "replace:\n  networking: " + filepath.Join(dir, "fury-kubernetes-networking") + "\n",
+			want:    map[string]string{"networking": filepath.Join(dir, "fury-kubernetes-networking")},
+		},
+		{
+			name:     "missing local path",
+			content:  "replace:\n  monitoring: ../fury-kubernetes-monitoring\n",
+			errorMsg: "override for package monitoring points to ../fury-kubernetes-monitoring",
+		},
+		{name: "invalid file", content: "replace: [networking]\n", errorMsg: "unable to decode"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Remove(defaultOverrideFile)
+			if tt.content != "" {
+				writeTestFiles(t, dir, map[string]string{defaultOverrideFile: tt.content})
+			}
+			got, err := loadOverride(defaultOverrideFile)
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("loadOverride() error = %v, want %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("loadOverride() error = %v", err)
+			}
+			if !reflect.DeepEqual(got.Replace, tt.want) {
+				t.Errorf("loadOverride() = %v, want %v", got.Replace, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOverride(t *testing.T) {
+	f := Furyconf{
+		VendorFolderName: "vendor",
+		Bases:            []Package{{Name: "networking/calico", Version: "v1.0.0"}, {Name: "monitoring", Version: "v1.0.0"}},
+		Charts:           []Chart{{Name: "ingress-nginx", Repository: "https://kubernetes.github.io/ingress-nginx", Version: "4.0.0"}},
+		replace: map[string]string{
+			"networking/calico": "/src/fury-kubernetes-networking/katalog/calico",
+			"ingress-nginx":     "/src/ingress-nginx",
+			"logging":           "/src/fury-kubernetes-logging",
+		},
+	}
+	list, err := f.Parse("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, p := range list {
+		got[p.Name] = p.override
+	}
+	want := map[string]string{
+		"networking/calico": "/src/fury-kubernetes-networking/katalog/calico",
+		"monitoring":        "",
+		"ingress-nginx":     "/src/ingress-nginx",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() overrides = %v, want %v", got, want)
+	}
+}
+
+func TestUseOverride(t *testing.T) {
+	dir, cleanup := inTempDir(t)
+	defer cleanup()
+	src := filepath.Join(dir, "fury-kubernetes-networking")
+	writeTestFiles(t, dir, map[string]string{
+		"fury-kubernetes-networking/katalog/calico/kustomization.yaml": "resources: []\n",
+		"fury-kubernetes-networking/.git/HEAD":                         "ref: refs/heads/main\n",
+	})
+
+	tests := []struct {
+		name      string
+		copyFiles bool
+	}{
+		{name: "symlink", copyFiles: false},
+		{name: "copy", copyFiles: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := filepath.Join(dir, "vendor", "katalog", "networking")
+			// a previous download must be replaced by the local checkout
+			writeTestFiles(t, dest, map[string]string{"remote.yaml": ""})
+
+			if err := useOverride(src, dest, tt.copyFiles); err != nil {
+				t.Fatalf("useOverride() error = %v", err)
+			}
+			info, err := os.Lstat(dest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if isLink := info.Mode()&os.ModeSymlink != 0; isLink == tt.copyFiles {
+				t.Errorf("useOverride() symlink = %v, want %v", isLink, !tt.copyFiles)
+			}
+			content, err := ioutil.ReadFile(filepath.Join(dest, "katalog", "calico", "kustomization.yaml"))
+			if err != nil || string(content) != "resources: []\n" {
+				t.Errorf("useOverride() kustomization.yaml = %q, %v", content, err)
+			}
+			if _, err = os.Stat(filepath.Join(dest, "remote.yaml")); !os.IsNotExist(err) {
+				t.Errorf("useOverride() kept the previous download: %v", err)
+			}
+			if _, err = os.Stat(filepath.Join(dest, ".git")); os.IsNotExist(err) == !tt.copyFiles {
+				t.Errorf("useOverride() .git folder: %v", err)
+			}
+			if _, err = os.Lstat(dest + ".tmp"); !os.IsNotExist(err) {
+				t.Errorf("useOverride() left the temporary directory: %v", err)
+			}
+			if err = removeDir(dest); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestGuardOverride(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir, cleanup := inTempDir(t)
+	defer cleanup()
+	if err := exec.Command("git", "init", "-q").Run(); err != nil {
+		t.Fatal(err)
+	}
+	writeTestFiles(t, dir, map[string]string{
+		defaultOverrideFile: "replace: {}\n",
+		gitignoreFile:       "/Furyfile.override.yml\nbin",
+	})
+
+	tests := []struct {
+		name      string
+		tracked   string
+		errorMsg  string
+		gitignore string
+	}{
+		{name: "untracked", gitignore: "/Furyfile.override.yml\nbin\nvendor/.furyctl-overrides.yml\n"},
+		{name: "tracked", tracked: defaultOverrideFile, errorMsg: "Furyfile.override.yml is tracked by git"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.tracked != "" {
+				if err := exec.Command("git", "add", "-f", tt.tracked).Run(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := guardOverride(defaultOverrideFile, "vendor")
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("guardOverride() error = %v, want %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("guardOverride() error = %v", err)
+			}
+			content, err := ioutil.ReadFile(gitignoreFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != tt.gitignore {
+				t.Errorf("guardOverride() .gitignore = %q, want %q", content, tt.gitignore)
+			}
+		})
+	}
+}
+
+func TestOverrideState(t *testing.T) {
+	dir, cleanup := inTempDir(t)
+	defer cleanup()
+	vendor := filepath.Join(dir, "vendor")
+
+	if state := readOverrideState(vendor); state != nil {
+		t.Errorf("readOverrideState() without a state = %v, want nil", state)
+	}
+	list := []Package{
+		{Name: "networking", dir: "vendor/katalog/networking", override: "/src/fury-kubernetes-networking"},
+		{Name: "monitoring", dir: "vendor/katalog/monitoring"},
+	}
+	if err := writeOverrideState(vendor, list); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"vendor/katalog/networking": "/src/fury-kubernetes-networking"}
+	if state := readOverrideState(vendor); !reflect.DeepEqual(state, want) {
+		t.Errorf("readOverrideState() = %v, want %v", state, want)
+	}
+
+	// a run without overrides removes the state
+	if err := writeOverrideState(vendor, list[1:]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(vendor, overrideStateFile)); !os.IsNotExist(err) {
+		t.Errorf("writeOverrideState() without overrides kept the state: %v", err)
+	}
+}
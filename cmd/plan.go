@@ -0,0 +1,123 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	planOutputTable = "table"
+	planOutputJSON  = "json"
+	planOutputTree  = "tree"
+)
+
+// PlanEntry describes how a single package is going to be vendored
+type PlanEntry struct {
+	Kind          string `json:"kind"`
+	Name          string `json:"name"`
+	Version       string `json:"version"`
+	Pattern       string `json:"pattern,omitempty"`
+	URL           string `json:"url"`
+	Dir           string `json:"dir"`
+	Override      string `json:"override,omitempty"`
+	Provider      string `json:"provider,omitempty"`
+	ProviderLabel string `json:"providerLabel,omitempty"`
+}
+
+func newPlan(packages []Package) []PlanEntry {
+	plan := make([]PlanEntry, 0, len(packages))
+	for _, p := range packages {
+		e := PlanEntry{
+			Kind:     p.kind,
+			Name:     p.Name,
+			Version:  p.resolvedVersion,
+			Pattern:  p.versionPattern,
+			URL:      p.url,
+			Dir:      p.dir,
+			Override: p.override,
+		}
+		if p.Registry {
+			e.Provider = p.ProviderOpt.Name
+			e.ProviderLabel = p.ProviderOpt.Label
+		}
+		plan = append(plan, e)
+	}
+	return plan
+}
+
+// printPlan writes the packages to be vendored in the requested format
+func printPlan(w io.Writer, packages []Package, format string) error {
+	plan := newPlan(packages)
+	switch format {
+	case planOutputTable:
+		return printPlanTable(w, plan)
+	case planOutputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(plan)
+	case planOutputTree:
+		return printPlanTree(w, plan)
+	default:
+		return fmt.Errorf("unknown output format %s. Choose one of: %s, %s, %s", format, planOutputTable, planOutputJSON, planOutputTree)
+	}
+}
+
+func printPlanTable(w io.Writer, plan []PlanEntry) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "KIND\tNAME\tVERSION\tPATTERN\tURL\tDIR")
+	for _, e := range plan {
+		pattern := e.Pattern
+		if pattern == "" {
+			pattern = "-"
+		}
+		url := e.URL
+		if e.Override != "" {
+			url = fmt.Sprintf("%s [OVERRIDE]", e.Override)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Kind, e.Name, e.Version, pattern, url, e.Dir)
+	}
+	return tw.Flush()
+}
+
+func printPlanTree(w io.Writer, plan []PlanEntry) error {
+	byKind := map[string][]PlanEntry{}
+	kinds := []string{}
+	for _, e := range plan {
+		if _, ok := byKind[e.Kind]; !ok {
+			kinds = append(kinds, e.Kind)
+		}
+		byKind[e.Kind] = append(byKind[e.Kind], e)
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		fmt.Fprintln(w, kind)
+		entries := byKind[kind]
+		sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+		for i, e := range entries {
+			branch, indent := "├── ", "│   "
+			if i == len(entries)-1 {
+				branch, indent = "└── ", "    "
+			}
+			source := e.Version
+			if e.Pattern != "" {
+				source = fmt.Sprintf("%s (%s)", e.Version, e.Pattern)
+			}
+			fmt.Fprintf(w, "%s%s@%s\n", branch, strings.Trim(e.Name, "/"), source)
+			if e.Override != "" {
+				fmt.Fprintf(w, "%s  override: %s\n", indent, e.Override)
+			} else {
+				fmt.Fprintf(w, "%s  url: %s\n", indent, e.URL)
+			}
+			fmt.Fprintf(w, "%s  dir: %s\n", indent, e.Dir)
+		}
+	}
+	return nil
+}
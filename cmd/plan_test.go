@@ -0,0 +1,97 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+const planFuryfile = `versions:
+  networking: v1.8.0
+  "monitoring/**": v1.14.0
+bases:
+  - name: networking/calico
+  - name: monitoring/prometheus-operator
+  - name: logging
+    version: v1.9.1
+modules:
+  - name: aws/vpc-and-vpn
+    version: v1.0.0
+charts:
+  - name: ingress-nginx
+    repository: https://kubernetes.github.io/ingress-nginx
+    version: 4.0.13
+`
+
+func TestPrintPlan(t *testing.T) {
+	testdata, err := filepath.Abs("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, cleanup := inTempDir(t)
+	defer cleanup()
+	writeTestFiles(t, dir, map[string]string{"Furyfile.yml": planFuryfile})
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "list", args: []string{"vendor", "list"}},
+		{name: "dry-run", args: []string{"vendor", "--dry-run"}},
+	}
+	for _, tt := range tests {
+		for _, format := range []string{planOutputTable, planOutputJSON, planOutputTree} {
+			t.Run(tt.name+"/"+format, func(t *testing.T) {
+				out := new(bytes.Buffer)
+				rootCmd.SetOut(out)
+				defer rootCmd.SetOut(nil)
+				rootCmd.SetArgs(append(tt.args, "--disable", "--output", format))
+				if err := rootCmd.Execute(); err != nil {
+					t.Fatal(err)
+				}
+				got := out.String()
+				golden := filepath.Join(testdata, "plan."+format)
+				if *update {
+					if err := ioutil.WriteFile(golden, []byte(got), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+				want, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != string(want) {
+					t.Errorf("%s plan =\n%s\nwant\n%s", format, got, want)
+				}
+			})
+		}
+	}
+
+	overridden := []Package{{Name: "logging", kind: "katalog", resolvedVersion: "v1.9.1", dir: "vendor/katalog/logging", override: "/src/fury-kubernetes-logging"}}
+	for format, want := range map[string]string{
+		planOutputTable: "/src/fury-kubernetes-logging [OVERRIDE]",
+		planOutputJSON:  `"override": "/src/fury-kubernetes-logging"`,
+		planOutputTree:  "override: /src/fury-kubernetes-logging",
+	} {
+		out := new(bytes.Buffer)
+		if err := printPlan(out, overridden, format); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("%s plan =\n%s\nwant the override %q", format, out, want)
+		}
+	}
+
+	if err := printPlan(new(bytes.Buffer), nil, "yaml"); err == nil || !strings.Contains(err.Error(), "unknown output format yaml") {
+		t.Errorf("printPlan() error = %v, want an unknown format", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	defaultReleaseIndex        = "https://api.github.com/repos/sighupio/fury-distribution/releases?per_page=100"
+	distributionReleasesPrefix = "https://github.com/sighupio/fury-distribution/releases/download/"
+	kfdFile                    = "kfd.yaml"
+)
+
+var releaseIndex string
+
+// nextPagePattern matches the next page of the Link header of the GitHub API responses
+var nextPagePattern = regexp.MustCompile(`<([^>]+)>;\s*rel="next"`)
+
+// ReleaseIndex is the abstraction of the file listing the fury-distribution releases, either the response of the
+// GitHub releases API or a file like:
+//
+//	releases:
+//	  - version: v1.7.0
+//	    date: "2021-07-26"
+//	    kubernetes: ["1.20", "1.21"]
+//	    modules:
+//	      networking: v1.7.0
+//	      monitoring: v1.13.0
+type ReleaseIndex struct {
+	Releases []Release `yaml:"releases" json:"releases"`
+}
+
+// Release describes a single fury-distribution release
+type Release struct {
+	Version    string            `yaml:"version" json:"version"`
+	Date       string            `yaml:"date" json:"date,omitempty"`
+	Kubernetes []string          `yaml:"kubernetes" json:"kubernetes"`
+	Modules    map[string]string `yaml:"modules" json:"modules"`
+	Notes      string            `yaml:"notes" json:"notes,omitempty"`
+	URL        string            `yaml:"url" json:"url,omitempty"`
+}
+
+// githubRelease is the subset of a release returned by the GitHub releases API
+type githubRelease struct {
+	TagName     string `json:"tag_name"`
+	PublishedAt string `json:"published_at"`
+	Body        string `json:"body"`
+	Draft       bool   `json:"draft"`
+	Prerelease  bool   `json:"prerelease"`
+	Assets      []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// kfd is the subset of the kfd.yaml file describing a release: the versions of its modules and, for each
+// Kubernetes installer, the Kubernetes version it installs
+type kfd struct {
+	Modules    map[string]string `yaml:"modules"`
+	Kubernetes map[string]struct {
+		Version string `yaml:"version"`
+	} `yaml:"kubernetes"`
+}
+
+// assetsURL returns the base url the release files are downloaded from
+func (r Release) assetsURL() string {
+	if r.URL != "" {
+		return strings.TrimSuffix(r.URL, "/") + "/"
+	}
+	return distributionReleasesPrefix + r.Version + "/"
+}
+
+// moduleNames returns the modules of the release sorted by name
+func (r Release) moduleNames() []string {
+	names := make([]string, 0, len(r.Modules))
+	for name := range r.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fillModules sets the modules of a release from the versions of its Furyfile when the index doesn't list them,
+// as the GitHub releases API
+func (r *Release) fillModules(furyfile []byte) error {
+	if len(r.Modules) > 0 {
+		return nil
+	}
+	var f struct {
+		Versions map[string]string `yaml:"versions"`
+	}
+	if err := yaml.Unmarshal(furyfile, &f); err != nil {
+		return fmt.Errorf("unable to decode the %s of the release %s: %v", furyFile, r.Version, err)
+	}
+	r.Modules = f.Versions
+	return nil
+}
+
+// fillKFD sets the modules and the Kubernetes versions of a release the index doesn't list from its kfd.yaml
+func (r *Release) fillKFD(content []byte) error {
+	var k kfd
+	if err := yaml.Unmarshal(content, &k); err != nil {
+		return fmt.Errorf("unable to decode the %s of the release %s: %v", kfdFile, r.Version, err)
+	}
+	if len(r.Modules) == 0 {
+		r.Modules = k.Modules
+	}
+	if len(r.Kubernetes) == 0 {
+		seen := map[string]bool{}
+		for _, installer := range k.Kubernetes {
+			if installer.Version != "" && !seen[installer.Version] {
+				seen[installer.Version] = true
+				r.Kubernetes = append(r.Kubernetes, installer.Version)
+			}
+		}
+		sort.Strings(r.Kubernetes)
+	}
+	return nil
+}
+
+// fetchDetails sets the Kubernetes versions and the modules of a release the index doesn't list, as the GitHub
+// releases API, reading its kfd.yaml. The releases without it only get the modules, from the versions of their Furyfile
+func (r *Release) fetchDetails() error {
+	if len(r.Kubernetes) > 0 && len(r.Modules) > 0 {
+		return nil
+	}
+	content, err := httpGet(r.assetsURL()+kfdFile, nil)
+	if err == nil {
+		return r.fillKFD(content)
+	}
+	if _, ok := err.(*httpNotFoundError); !ok {
+		return err
+	}
+	content, err = httpGet(r.assetsURL()+furyFile, nil)
+	if err != nil {
+		return err
+	}
+	return r.fillModules(content)
+}
+
+// fetchDetails fetches the details of every release missing them in parallel. The failures are reported and
+// leave the details of their release empty
+func (i *ReleaseIndex) fetchDetails() {
+	var wg sync.WaitGroup
+	jobs := make(chan *Release, len(i.Releases))
+	for n := range i.Releases {
+		jobs <- &i.Releases[n]
+	}
+	close(jobs)
+	for w := 0; w < runtime.NumCPU()+1; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range jobs {
+				if err := r.fetchDetails(); err != nil {
+					logrus.Warnf("unable to read the Kubernetes versions and the modules of the release %s: %v", r.Version, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// fetchReleaseIndex downloads and decodes the release index, newest release first.
+// The GitHub releases API is read following its pages
+func fetchReleaseIndex(url string) (*ReleaseIndex, error) {
+	content, header, err := httpGetResponse(url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch the release index: %v", err)
+	}
+	index := &ReleaseIndex{}
+	if bytes.HasPrefix(bytes.TrimSpace(content), []byte("[")) {
+		index.Releases = []Release{}
+		for page := url; ; {
+			var releases *ReleaseIndex
+			if releases, err = decodeGitHubReleases(content); err != nil {
+				return nil, fmt.Errorf("unable to decode the release index %s: %v", page, err)
+			}
+			index.Releases = append(index.Releases, releases.Releases...)
+			next := nextPagePattern.FindStringSubmatch(header.Get("Link"))
+			if next == nil {
+				break
+			}
+			page = next[1]
+			if content, header, err = httpGetResponse(page, nil); err != nil {
+				return nil, fmt.Errorf("unable to fetch the release index: %v", err)
+			}
+		}
+	} else if err = yaml.Unmarshal(content, index); err != nil {
+		return nil, fmt.Errorf("unable to decode the release index %s: %v", url, err)
+	}
+	for _, r := range index.Releases {
+		if _, err = goversion.NewVersion(r.Version); err != nil {
+			return nil, fmt.Errorf("release index %s: invalid release version %s", url, r.Version)
+		}
+	}
+	sort.SliceStable(index.Releases, func(i, j int) bool {
+		vi, _ := goversion.NewVersion(index.Releases[i].Version)
+		vj, _ := goversion.NewVersion(index.Releases[j].Version)
+		return vi.GreaterThan(vj)
+	})
+	return index, nil
+}
+
+// decodeGitHubReleases builds the index from the published releases returned by the GitHub releases API,
+// skipping the drafts, the prereleases and the tags that are not versions
+func decodeGitHubReleases(content []byte) (*ReleaseIndex, error) {
+	var releases []githubRelease
+	if err := json.Unmarshal(content, &releases); err != nil {
+		return nil, err
+	}
+	index := &ReleaseIndex{Releases: []Release{}}
+	for _, r := range releases {
+		if r.Draft || r.Prerelease {
+			continue
+		}
+		if _, err := goversion.NewVersion(r.TagName); err != nil {
+			continue
+		}
+		date := r.PublishedAt
+		if len(date) > len("2006-01-02") {
+			date = date[:len("2006-01-02")]
+		}
+		release := Release{Version: r.TagName, Date: date, Notes: r.Body}
+		// the assets are downloaded from the location of the release Furyfile
+		for _, asset := range r.Assets {
+			if asset.Name == furyFile {
+				release.URL = strings.TrimSuffix(asset.BrowserDownloadURL, furyFile)
+			}
+		}
+		index.Releases = append(index.Releases, release)
+	}
+	return index, nil
+}
+
+// find returns the release with the given version
+func (i *ReleaseIndex) find(v string) (Release, error) {
+	versions := make([]string, 0, len(i.Releases))
+	for _, r := range i.Releases {
+		if r.Version == v || r.Version == "v"+v {
+			return r, nil
+		}
+		versions = append(versions, r.Version)
+	}
+	return Release{}, fmt.Errorf("distribution release %s not found. Available releases: %s", v, strings.Join(versions, ", "))
+}
@@ -0,0 +1,296 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// releaseServer serves a release index and the files of its releases
+func releaseServer(t *testing.T, files map[string]string) *httptest.Server {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/releases.yml" {
+			fmt.Fprintf(w, `releases:
+  - version: v1.6.0
+    kubernetes: ["1.19", "1.20"]
+    modules:
+      networking: v1.6.0
+    url: %[1]s/v1.6.0
+  - version: v1.7.0
+    date: "2021-07-26"
+    kubernetes: ["1.20", "1.21"]
+    modules:
+      networking: v1.7.0
+      monitoring: v1.13.0
+    url: %[1]s/v1.7.0/
+`, srv.URL)
+			return
+		}
+		if content, ok := files[r.URL.Path]; ok {
+			fmt.Fprint(w, content)
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	return srv
+}
+
+func TestReleaseIndex(t *testing.T) {
+	srv := releaseServer(t, nil)
+	defer srv.Close()
+
+	index, err := fetchReleaseIndex(srv.URL + "/releases.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Releases) != 2 || index.Releases[0].Version != "v1.7.0" {
+		t.Fatalf("releases = %v, want the newest first", index.Releases)
+	}
+
+	r, err := index.find("1.6.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.assetsURL() != srv.URL+"/v1.6.0/" {
+		t.Errorf("assetsURL() = %s", r.assetsURL())
+	}
+	_, err = index.find("v1.8.0")
+	if err == nil || !strings.Contains(err.Error(), "Available releases: v1.7.0, v1.6.0") {
+		t.Errorf("find() error = %v, want the available releases", err)
+	}
+
+	var out bytes.Buffer
+	if err = printReleases(&out, index.Releases, planOutputTable); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "1.20, 1.21") || !strings.Contains(out.String(), "monitoring@v1.13.0 networking@v1.7.0") {
+		t.Errorf("unexpected table:\n%s", out.String())
+	}
+
+	if _, err = fetchReleaseIndex(srv.URL + "/missing.yml"); err == nil {
+		t.Error("fetchReleaseIndex() of a missing index should fail")
+	}
+}
+
+const releaseKFD = `version: v1.7.0
+modules:
+  networking: v1.7.0
+  monitoring: v1.13.0
+kubernetes:
+  eks:
+    version: "1.21"
+  onpremises:
+    version: "1.20"
+`
+
+func TestReleaseIndexFromGitHub(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/download/v1.7.0/kfd.yaml":
+			fmt.Fprint(w, releaseKFD)
+			return
+		case "/download/v1.6.0/Furyfile.yml":
+			fmt.Fprint(w, releaseFuryfile)
+			return
+		case "/repos/sighupio/fury-distribution/releases":
+		default:
+			http.NotFound(w, r)
+			return
+		}
+		if r.URL.Query().Get("page") == "2" {
+			fmt.Fprintf(w, `[
+  {"tag_name": "latest", "published_at": "2021-07-26T09:30:00Z"},
+  {"tag_name": "v1.6.0", "published_at": "2021-05-10T08:00:00Z", "assets": [{"name": "Furyfile.yml", "browser_download_url": "%s/download/v1.6.0/Furyfile.yml"}]}
+]`, srv.URL)
+			return
+		}
+		w.Header().Set("Link", fmt.Sprintf(`<%[1]s%[2]s?page=2>; rel="next", <%[1]s%[2]s?page=2>; rel="last"`, srv.URL, r.URL.Path))
+		fmt.Fprintf(w, `[
+  {"tag_name": "v1.8.0-rc.1", "published_at": "2021-10-01T10:00:00Z", "prerelease": true},
+  {"tag_name": "v1.7.0", "published_at": "2021-07-26T09:30:00Z", "body": "Upgrade the networking module first", "assets": [{"name": "Furyfile.yml", "browser_download_url": "%s/download/v1.7.0/Furyfile.yml"}]},
+  {"tag_name": "v1.8.0", "published_at": "2021-10-20T10:00:00Z", "draft": true}
+]`, srv.URL)
+	}))
+	defer srv.Close()
+
+	index, err := fetchReleaseIndex(srv.URL + "/repos/sighupio/fury-distribution/releases")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Release{
+		{Version: "v1.7.0", Date: "2021-07-26", Notes: "Upgrade the networking module first", URL: srv.URL + "/download/v1.7.0/"},
+		{Version: "v1.6.0", Date: "2021-05-10", URL: srv.URL + "/download/v1.6.0/"},
+	}
+	if !reflect.DeepEqual(index.Releases, want) {
+		t.Fatalf("releases = %+v, want %+v", index.Releases, want)
+	}
+
+	index.fetchDetails()
+	if got := index.Releases[0]; !reflect.DeepEqual(got.Kubernetes, []string{"1.20", "1.21"}) || got.Modules["monitoring"] != "v1.13.0" {
+		t.Errorf("fetchDetails() = %+v, want the details of the kfd.yaml", got)
+	}
+	if got := index.Releases[1]; len(got.Kubernetes) != 0 || got.Modules["networking"] != "v1.8.2" {
+		t.Errorf("fetchDetails() without a kfd.yaml = %+v, want the Furyfile versions", got)
+	}
+
+	out := new(bytes.Buffer)
+	rootCmd.SetOut(out)
+	defer rootCmd.SetOut(nil)
+	rootCmd.SetArgs([]string{"distribution", "list", "--index", srv.URL + "/repos/sighupio/fury-distribution/releases"})
+	if err = rootCmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "v1.7.0   1.20, 1.21") || !strings.Contains(out.String(), "monitoring@v1.13.0 networking@v1.7.0") {
+		t.Errorf("distribution list =\n%s\nwant the Kubernetes versions and the modules", out)
+	}
+}
+
+func TestFindRelease(t *testing.T) {
+	srv := releaseServer(t, nil)
+	defer srv.Close()
+
+	r, err := findRelease(srv.URL+"/releases.yml", "v1.7.0")
+	if err != nil || r.assetsURL() != srv.URL+"/v1.7.0/" {
+		t.Errorf("findRelease() = %+v, %v, want the release of the index", r, err)
+	}
+	if _, err = findRelease(srv.URL+"/releases.yml", "v1.8.0"); err == nil || !strings.Contains(err.Error(), "furyctl distribution list") {
+		t.Errorf("findRelease() error = %v, want a missing release", err)
+	}
+	if _, err = findRelease(srv.URL+"/missing.yml", "v1.8.0"); err == nil || !strings.Contains(err.Error(), "Use --skip-index") {
+		t.Errorf("findRelease() without an index error = %v, want the index failure", err)
+	}
+
+	defer func() { skipIndex = false }()
+	skipIndex = true
+	r, err = findRelease(srv.URL+"/missing.yml", "v1.8.0")
+	if err != nil {
+		t.Fatalf("findRelease() with --skip-index error = %v", err)
+	}
+	if r.assetsURL() != distributionReleasesPrefix+"v1.8.0/" {
+		t.Errorf("findRelease() with --skip-index assetsURL() = %s, want the release assets", r.assetsURL())
+	}
+}
+
+func TestDownloadRelease(t *testing.T) {
+	checksums := fmt.Sprintf("%s  Furyfile.yml\n%s  kustomization.yaml\n", sha256Hex([]byte(releaseFuryfile)), sha256Hex([]byte(releaseKustomization)))
+	files := map[string]string{
+		"/v1.7.0/Furyfile.yml":       releaseFuryfile,
+		"/v1.7.0/kustomization.yaml": releaseKustomization,
+		"/v1.7.0/checksums.txt":      checksums,
+		"/v1.6.0/Furyfile.yml":       "tampered",
+		"/v1.6.0/kustomization.yaml": releaseKustomization,
+		"/v1.6.0/checksums.txt":      checksums,
+	}
+	srv := releaseServer(t, files)
+	defer srv.Close()
+
+	index, err := fetchReleaseIndex(srv.URL + "/releases.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "furyctl-release")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	downloaded, err := downloadRelease(index.Releases[0], dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(downloaded[furyFile]) != releaseFuryfile || string(downloaded[kustomizationFile]) != releaseKustomization {
+		t.Errorf("unexpected release files: %v", downloaded)
+	}
+
+	_, err = downloadRelease(index.Releases[1], dir)
+	if err == nil || !strings.Contains(err.Error(), "Furyfile.yml of the release v1.6.0: digest mismatch") {
+		t.Errorf("downloadRelease() error = %v, want a digest mismatch", err)
+	}
+
+	unsigned := releaseServer(t, map[string]string{"/v1.7.0/Furyfile.yml": releaseFuryfile, "/v1.7.0/kustomization.yaml": releaseKustomization})
+	defer unsigned.Close()
+	index, err = fetchReleaseIndex(unsigned.URL + "/releases.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = downloadRelease(index.Releases[0], dir)
+	if err == nil || !strings.Contains(err.Error(), "unable to download the checksums.txt of the release v1.7.0") {
+		t.Errorf("downloadRelease() of a release without checksums error = %v, want the missing checksums", err)
+	}
+
+	partial := releaseServer(t, map[string]string{
+		"/v1.7.0/Furyfile.yml":       releaseFuryfile,
+		"/v1.7.0/kustomization.yaml": releaseKustomization,
+		"/v1.7.0/checksums.txt":      sha256Hex([]byte(releaseFuryfile)) + "  Furyfile.yml\n",
+	})
+	defer partial.Close()
+	_, err = downloadRelease(Release{Version: "v1.7.0", URL: partial.URL + "/v1.7.0"}, dir)
+	if err == nil || !strings.Contains(err.Error(), "kustomization.yaml of the release v1.7.0 has no checksum in checksums.txt") {
+		t.Errorf("downloadRelease() of a file without checksum error = %v, want the missing entry", err)
+	}
+
+	defer func() { skipChecksums = false }()
+	skipChecksums = true
+	downloaded, err = downloadRelease(index.Releases[0], dir)
+	if err != nil {
+		t.Fatalf("downloadRelease() with --skip-checksums error = %v", err)
+	}
+	if string(downloaded[furyFile]) != releaseFuryfile || string(downloaded[kustomizationFile]) != releaseKustomization {
+		t.Errorf("unexpected release files with --skip-checksums: %v", downloaded)
+	}
+	skipChecksums = false
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	_, err = downloadRelease(Release{Version: "v1.7.0", URL: failing.URL}, dir)
+	if err == nil || !strings.Contains(err.Error(), "unable to download the checksums.txt of the release v1.7.0") {
+		t.Errorf("downloadRelease() error = %v, want the checksums download failure", err)
+	}
+}
+
+func TestWriteProjectFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-project")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	furyfile := filepath.Join(dir, furyFile)
+	kustomization := filepath.Join(dir, kustomizationFile)
+	if err = ioutil.WriteFile(furyfile, []byte("local"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := []projectFile{{furyfile, []byte("upstream")}, {kustomization, []byte("upstream")}}
+	err = writeProjectFiles(files, false)
+	if err == nil || !strings.Contains(err.Error(), "refusing to overwrite "+furyfile) {
+		t.Fatalf("writeProjectFiles() error = %v, want a refusal", err)
+	}
+	if _, err = os.Stat(kustomization); !os.IsNotExist(err) {
+		t.Error("no file should be written when one is refused")
+	}
+
+	if err = writeProjectFiles(files, true); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := ioutil.ReadFile(furyfile); string(content) != "upstream" {
+		t.Errorf("%s not overwritten with --force", furyFile)
+	}
+	if err = writeProjectFiles(files, false); err != nil {
+		t.Errorf("writeProjectFiles() of unchanged files error = %v", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"strings"
 
@@ -23,9 +24,21 @@ var (
 	cfg *configuration.Configuration
 )
 
-func parseConfig(path string, kind string) (err error) {
-	log.Debugf("parsing configuration file %v", path)
-	cfg, err = configuration.Parse(path)
+func parseConfig(paths []string, kind string, allowUnknownFields bool) (err error) {
+	log.Debugf("parsing configuration files %v", paths)
+	if len(paths) == 0 {
+		return errors.New("missing the configuration file")
+	}
+	r, source, err := configuration.Open(paths[0])
+	if err != nil {
+		log.Errorf("error reading configuration file: %v", err)
+		return err
+	}
+	defer r.Close()
+	cfg, err = configuration.ParseWithOptions(r, source, configuration.Options{
+		Overlays:           paths[1:],
+		AllowUnknownFields: allowUnknownFields,
+	})
 	if err != nil {
 		log.Errorf("error parsing configuration file: %v", err)
 		return err
@@ -38,6 +51,39 @@ func parseConfig(path string, kind string) (err error) {
 	return nil
 }
 
+// readsStdin tells whether a configuration file is read from the standard input
+func readsStdin(paths []string) bool {
+	for _, path := range paths {
+		if path == configuration.Stdin {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTemplate prints the template configuration file of a kind and provisioner, or writes it to output when set
+func writeTemplate(kind, provisioner string, minimal, full bool, output string) error {
+	if provisioner == "" {
+		return errors.New("You must specify a provisioner")
+	}
+	if minimal && full {
+		return errors.New("--minimal and --full are mutually exclusive")
+	}
+	tpl, err := configuration.TemplateWithOptions(kind, provisioner, configuration.TemplateOptions{Minimal: minimal})
+	if err != nil {
+		return err
+	}
+	if output == "" {
+		fmt.Print(tpl)
+		return nil
+	}
+	if err = ioutil.WriteFile(output, []byte(tpl), 0644); err != nil {
+		return err
+	}
+	log.Infof("%s template written to %s", kind, output)
+	return nil
+}
+
 func warning(command string) {
 	fmt.Printf(`
   Forced stop of the %v process.
@@ -0,0 +1,196 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	gitSSHCommandEnv = "GIT_SSH_COMMAND"
+	sshAuthSockEnv   = "SSH_AUTH_SOCK"
+	preflightTimeout = 30 * time.Second
+)
+
+var sshPrivateKey string
+var sshAgent bool
+var sshKnownHosts string
+var skipPreflight bool
+
+// SSHConf contains the ssh settings used to download packages when https is not enabled
+type SSHConf struct {
+	PrivateKey string `yaml:"privateKey"`
+	// Agent is nil when not set, leaving the ssh-agent usage to the local ssh config
+	Agent      *bool  `yaml:"agent"`
+	KnownHosts string `yaml:"knownHosts"`
+}
+
+// mergeFlags overrides the Furyfile ssh settings with the ones coming from the command line
+func (s SSHConf) mergeFlags(key string, agent bool, agentSet bool, knownHosts string) SSHConf {
+	if key != "" {
+		s.PrivateKey = key
+	}
+	if agentSet {
+		s.Agent = &agent
+	}
+	if knownHosts != "" {
+		s.KnownHosts = knownHosts
+	}
+	return s
+}
+
+func (s SSHConf) configured() bool {
+	return s.PrivateKey != "" || s.Agent != nil || s.KnownHosts != ""
+}
+
+// command returns the ssh command git has to use according to the settings
+func (s SSHConf) command() (string, error) {
+	args := []string{"ssh", "-o", "BatchMode=yes"}
+	if s.Agent != nil && *s.Agent && os.Getenv(sshAuthSockEnv) == "" {
+		return "", fmt.Errorf("ssh-agent requested but %s is not set. Start an agent with: eval $(ssh-agent) && ssh-add", sshAuthSockEnv)
+	}
+	if s.Agent != nil && !*s.Agent {
+		args = append(args, "-o", "IdentityAgent=none")
+	}
+	if s.PrivateKey != "" {
+		key, err := expandHome(s.PrivateKey)
+		if err != nil {
+			return "", err
+		}
+		if _, err = os.Stat(key); err != nil {
+			return "", fmt.Errorf("ssh private key: %v", err)
+		}
+		args = append(args, "-i", shellQuote(key), "-o", "IdentitiesOnly=yes")
+	}
+	if s.KnownHosts != "" {
+		knownHosts, err := expandHome(s.KnownHosts)
+		if err != nil {
+			return "", err
+		}
+		if _, err = os.Stat(knownHosts); err != nil {
+			return "", fmt.Errorf("ssh known_hosts: %v", err)
+		}
+		args = append(args, "-o", "UserKnownHostsFile="+shellQuote(knownHosts), "-o", "StrictHostKeyChecking=yes")
+	}
+	return strings.Join(args, " "), nil
+}
+
+// configureSSH exports the ssh command used by git for every download
+func configureSSH(s SSHConf) error {
+	if !s.configured() {
+		return nil
+	}
+	command, err := s.command()
+	if err != nil {
+		return err
+	}
+	logrus.Debugf("using %s=%s", gitSSHCommandEnv, command)
+	return os.Setenv(gitSSHCommandEnv, command)
+}
+
+// sshPreflight checks every ssh repository is reachable and authenticated before downloading anything
+func sshPreflight(packages []Package) error {
+	repos := map[string][]string{}
+	for _, p := range packages {
+		if p.override != "" || p.chart != nil || !isSSHURL(p.url) {
+			continue
+		}
+		repo := gitRepository(p.url)
+		repos[repo] = append(repos[repo], p.Name)
+	}
+	if len(repos) == 0 {
+		return nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return errors.New("git is required to download packages")
+	}
+
+	names := make([]string, 0, len(repos))
+	for repo := range repos {
+		names = append(names, repo)
+	}
+	sort.Strings(names)
+	for _, repo := range names {
+		logrus.Debugf("preflight: checking access to %s", repo)
+		err := gitLsRemote(repo)
+		if err != nil {
+			return fmt.Errorf("package %s: unable to access %s over ssh: %v. Check the ssh settings or use --https", strings.Join(repos[repo], ", "), repo, err)
+		}
+	}
+	logrus.Infof("preflight: ssh access to %d repositories verified", len(names))
+	return nil
+}
+
+func gitLsRemote(repo string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), preflightTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", "ls-remote", "--exit-code", repo, "HEAD")
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if os.Getenv(gitSSHCommandEnv) == "" {
+		cmd.Env = append(cmd.Env, gitSSHCommandEnv+"=ssh -o BatchMode=yes")
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %v", preflightTimeout)
+	}
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			return err
+		}
+		return errors.New(strings.TrimSuffix(strings.Join(strings.Fields(msg), " "), "."))
+	}
+	return nil
+}
+
+// isSSHURL returns true for the scp-like and ssh:// git urls
+func isSSHURL(url string) bool {
+	url = strings.TrimPrefix(url, "git::")
+	return strings.HasPrefix(url, "ssh://") || (strings.Contains(url, "@") && !strings.Contains(url, "://"))
+}
+
+// gitRepository strips the subdirectory and the query from a go-getter git url
+func gitRepository(url string) string {
+	url = strings.TrimPrefix(url, "git::")
+	if i := strings.Index(url, "?"); i >= 0 {
+		url = url[:i]
+	}
+	start := 0
+	if i := strings.Index(url, "://"); i >= 0 {
+		start = i + 3
+	}
+	if i := strings.Index(url[start:], "//"); i >= 0 {
+		url = url[:start+i]
+	}
+	return url
+}
+
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return filepath.Abs(path)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSSHCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-ssh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	key := filepath.Join(dir, "id_rsa")
+	knownHosts := filepath.Join(dir, "known_hosts")
+	writeTestFiles(t, dir, map[string]string{"id_rsa": "", "known_hosts": ""})
+
+	defer os.Setenv(sshAuthSockEnv, os.Getenv(sshAuthSockEnv))
+	enabled, disabled := true, false
+
+	tests := []struct {
+		name     string
+		conf     SSHConf
+		authSock string
+		want     string
+		errorMsg string
+	}{
+		{name: "defaults", want: "ssh -o BatchMode=yes"},
+		{name: "agent", conf: SSHConf{Agent: &enabled}, authSock: "/tmp/agent.sock", want: "ssh -o BatchMode=yes"},
+		{name: "agent without socket", conf: SSHConf{Agent: &enabled}, errorMsg: "ssh-agent requested but SSH_AUTH_SOCK is not set"},
+		{name: "agent disabled", conf: SSHConf{Agent: &disabled}, authSock: "/tmp/agent.sock", want: "ssh -o BatchMode=yes -o IdentityAgent=none"},
+		{
+			name:     "private key",
+			conf:     SSHConf{PrivateKey: key},
+			authSock: "/tmp/agent.sock",
+			want:     "ssh -o BatchMode=yes -i '" + key + "' -o IdentitiesOnly=yes",
+		},
+		{name: "missing private key", conf: SSHConf{PrivateKey: filepath.Join(dir, "missing")}, errorMsg: "ssh private key"},
+		{
+			name: "known hosts",
+			conf: SSHConf{KnownHosts: knownHosts, Agent: &disabled},
+			want: "ssh -o BatchMode=yes -o IdentityAgent=none -o UserKnownHostsFile='" + knownHosts + "' -o StrictHostKeyChecking=yes",
+		},
+		{name: "missing known hosts", conf: SSHConf{KnownHosts: filepath.Join(dir, "missing")}, errorMsg: "ssh known_hosts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Setenv(sshAuthSockEnv, tt.authSock)
+			got, err := tt.conf.command()
+			if tt.errorMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errorMsg) {
+					t.Errorf("command() error = %v, want %q", err, tt.errorMsg)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("command() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("command() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSHMergeFlags(t *testing.T) {
+	enabled := true
+	conf := SSHConf{PrivateKey: "furyfile_key", Agent: &enabled}
+
+	if got := conf.mergeFlags("", false, false, ""); got.Agent == nil || !*got.Agent || got.PrivateKey != "furyfile_key" {
+		t.Errorf("mergeFlags() without flags = %+v, want the Furyfile settings", got)
+	}
+	got := conf.mergeFlags("flag_key", false, true, "known_hosts")
+	if got.Agent == nil || *got.Agent || got.PrivateKey != "flag_key" || got.KnownHosts != "known_hosts" {
+		t.Errorf("mergeFlags() = %+v, want the flags settings", got)
+	}
+	if (SSHConf{}).configured() {
+		t.Error("configured() of empty settings = true")
+	}
+}
+
+func TestIsSSHURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want bool
+	}{
+		{url: "git@github.com:sighupio/fury-kubernetes-networking//katalog/calico?ref=v1.8.0", want: true},
+		{url: "git::git@github.com:sighupio/fury-kubernetes-networking", want: true},
+		{url: "ssh://git@github.com/sighupio/fury-kubernetes-networking.git", want: true},
+		{url: "git::ssh://git@github.com/sighupio/fury-kubernetes-networking.git", want: true},
+		{url: "https://github.com/sighupio/fury-kubernetes-networking.git//katalog/calico?ref=v1.8.0", want: false},
+		{url: "git::https://user@github.com/sighupio/fury-kubernetes-networking.git", want: false},
+		{url: "github.com/sighupio/fury-kubernetes-networking", want: false},
+		{url: "https://kubernetes.github.io/ingress-nginx#ingress-nginx", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := isSSHURL(tt.url); got != tt.want {
+				t.Errorf("isSSHURL(%s) = %v, want %v", tt.url, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	goversion "github.com/hashicorp/go-version"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+const distributionStateFile = ".furyctl-distribution.yml"
+
+var upgradeFrom string
+var upgradeTo string
+var upgradeDryRun bool
+
+func init() {
+	distributionCmd.AddCommand(distributionUpgradeCmd)
+	distributionUpgradeCmd.Flags().StringVar(&upgradeTo, "to", "", "Distribution version to upgrade to")
+	distributionUpgradeCmd.Flags().StringVar(&upgradeFrom, "from", "", "Distribution version the project was initialized with. Defaults to the one recorded in "+distributionStateFile)
+	distributionUpgradeCmd.Flags().BoolVar(&skipChecksums, "skip-checksums", false, "if true does not verify the release files against the release "+checksumsFile)
+	distributionUpgradeCmd.Flags().BoolVar(&upgradeDryRun, "dry-run", false, "if true prints the changes without writing any file")
+	err := distributionUpgradeCmd.MarkFlagRequired("to")
+	if err != nil {
+		logrus.Print(err)
+	}
+}
+
+// distributionState is the abstraction of the file recording the distribution release a project is based on
+type distributionState struct {
+	Version string `yaml:"version"`
+	// Modules are the distribution modules picked at init, every module of the release when empty
+	Modules []string `yaml:"modules,omitempty"`
+}
+
+func readDistributionState() (distributionState, error) {
+	state := distributionState{}
+	content, err := ioutil.ReadFile(distributionStateFile)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err = yaml.Unmarshal(content, &state); err != nil {
+		return state, fmt.Errorf("unable to decode %s: %v", distributionStateFile, err)
+	}
+	return state, nil
+}
+
+func writeDistributionState(state distributionState) error {
+	content, err := yaml.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(distributionStateFile, content, 0644)
+}
+
+// distributionUpgradeCmd represents the distribution upgrade command
+var distributionUpgradeCmd = &cobra.Command{
+	Use:   "upgrade",
+	Short: "Upgrade the project to a new distribution release",
+	Long: `Upgrade the Furyfile.yml and kustomization.yaml files of the project to a new distribution release.
+
+The files of the release the project is based on, the files of the new release and the local files are merged
+keeping the local customizations. The release files are reduced to the modules picked at init, recorded in
+` + distributionStateFile + `, as the local ones. When the same value changed both locally and upstream the conflict is reported
+and the upgrade fails without writing any file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		state, err := readDistributionState()
+		if err != nil {
+			return err
+		}
+		if upgradeFrom == "" {
+			upgradeFrom = state.Version
+			if upgradeFrom == "" {
+				return fmt.Errorf("unable to know the current distribution version: %s not found. Use --from", distributionStateFile)
+			}
+		}
+
+		index, err := fetchReleaseIndex(releaseIndex)
+		if err != nil {
+			return err
+		}
+		from, err := index.find(upgradeFrom)
+		if err != nil {
+			return err
+		}
+		to, err := index.find(upgradeTo)
+		if err != nil {
+			return err
+		}
+		fromVersion, _ := goversion.NewVersion(from.Version)
+		toVersion, _ := goversion.NewVersion(to.Version)
+		if !toVersion.GreaterThan(fromVersion) {
+			return fmt.Errorf("%s is not newer than the current distribution %s", to.Version, from.Version)
+		}
+
+		tmp, err := ioutil.TempDir("", "furyctl-upgrade")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tmp)
+		base, err := downloadRelease(from, filepath.Join(tmp, from.Version))
+		if err != nil {
+			return err
+		}
+		upstream, err := downloadRelease(to, filepath.Join(tmp, to.Version))
+		if err != nil {
+			return err
+		}
+		// the modules left out at init must not be merged back, nor reported as conflicts
+		if base, err = filterRelease(base, state.Modules); err != nil {
+			return err
+		}
+		if upstream, err = filterRelease(upstream, state.Modules); err != nil {
+			return err
+		}
+		if err = from.fillModules(base[furyFile]); err != nil {
+			return err
+		}
+		if err = to.fillModules(upstream[furyFile]); err != nil {
+			return err
+		}
+
+		printModuleChanges(os.Stdout, from, to)
+		printUpgradeNotes(os.Stdout, index, fromVersion, toVersion)
+
+		merged, err := mergeRelease(base, upstream)
+		if err != nil {
+			return err
+		}
+		if upgradeDryRun {
+			for _, fileName := range fileNames {
+				fmt.Printf("\n# %s\n%s", fileName, merged[fileName])
+			}
+			return nil
+		}
+		for _, fileName := range fileNames {
+			logrus.Infof("writing %s", fileName)
+			if err = ioutil.WriteFile(fileName, merged[fileName], 0644); err != nil {
+				return err
+			}
+		}
+		if err = writeDistributionState(distributionState{Version: to.Version, Modules: state.Modules}); err != nil {
+			return err
+		}
+		logrus.Infof("upgraded to %s", to.Version)
+		return nil
+	},
+}
+
+// mergeRelease merges the local project files with the changes between the base and the upstream release files.
+// The conflicts are reported and fail the merge, no file has to be written until they are solved locally
+func mergeRelease(base, upstream map[string][]byte) (map[string][]byte, error) {
+	merged := map[string][]byte{}
+	total := 0
+	for _, fileName := range fileNames {
+		local, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			return nil, err
+		}
+		content, conflicts, err := merge3(base[fileName], local, upstream[fileName])
+		if err != nil {
+			return nil, fmt.Errorf("unable to merge %s: %v", fileName, err)
+		}
+		for _, c := range conflicts {
+			logrus.Errorf("%s: conflict on %s. base: %s local: %s upstream: %s", fileName, c.Path, inline(c.Base), inline(c.Local), inline(c.Upstream))
+		}
+		total += len(conflicts)
+		merged[fileName] = content
+	}
+	if total > 0 {
+		return nil, fmt.Errorf("%d conflicts between the local and the upstream changes, no file was written. Set the reported local values to the base or the upstream ones and run the upgrade again", total)
+	}
+	return merged, nil
+}
+
+// printModuleChanges prints the modules added, removed or updated between two releases
+func printModuleChanges(w io.Writer, from, to Release) {
+	names := map[string]bool{}
+	for name := range from.Modules {
+		names[name] = true
+	}
+	for name := range to.Modules {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	fmt.Fprintf(w, "Module changes from %s to %s:\n", from.Version, to.Version)
+	for _, name := range sorted {
+		old, hadOld := from.Modules[name]
+		latest, hasNew := to.Modules[name]
+		switch {
+		case !hadOld:
+			fmt.Fprintf(w, "  + %s %s\n", name, latest)
+		case !hasNew:
+			fmt.Fprintf(w, "  - %s %s\n", name, old)
+		case old != latest:
+			fmt.Fprintf(w, "  ~ %s %s -> %s\n", name, old, latest)
+		}
+	}
+}
+
+// printUpgradeNotes prints the notes of every release after from up to to
+func printUpgradeNotes(w io.Writer, index *ReleaseIndex, from, to *goversion.Version) {
+	for i := len(index.Releases) - 1; i >= 0; i-- {
+		r := index.Releases[i]
+		v, _ := goversion.NewVersion(r.Version)
+		if !v.GreaterThan(from) || v.GreaterThan(to) || r.Notes == "" {
+			continue
+		}
+		fmt.Fprintf(w, "\nUpgrade notes for %s:\n", r.Version)
+		for _, line := range strings.Split(strings.TrimSpace(r.Notes), "\n") {
+			fmt.Fprintf(w, "  %s\n", line)
+		}
+	}
+}
+
+func inline(v interface{}) string {
+	if v == nil {
+		return "<none>"
+	}
+	out, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return strings.Join(strings.Fields(string(out)), " ")
+}
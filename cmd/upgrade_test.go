@@ -0,0 +1,66 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// releaseFiles returns the files served for a release, with their checksums
+func releaseFiles(version, furyfile, kustomization string) map[string]string {
+	return map[string]string{
+		"/" + version + "/Furyfile.yml":       furyfile,
+		"/" + version + "/kustomization.yaml": kustomization,
+		"/" + version + "/checksums.txt":      fmt.Sprintf("%s  Furyfile.yml\n%s  kustomization.yaml\n", sha256Hex([]byte(furyfile)), sha256Hex([]byte(kustomization))),
+	}
+}
+
+func TestUpgradeSelectedModules(t *testing.T) {
+	files := releaseFiles("v1.6.0",
+		"versions:\n  networking: v1.6.0\n  monitoring: v1.13.0\nbases:\n  - name: networking/\n  - name: monitoring/\n",
+		"resources:\n  - ./vendor/katalog/networking/calico\n  - ./vendor/katalog/monitoring/prometheus-operator\n")
+	// the monitoring module, left out at init, is bumped upstream
+	for path, content := range releaseFiles("v1.7.0",
+		"versions:\n  networking: v1.7.0\n  monitoring: v1.14.0\nbases:\n  - name: networking/\n  - name: monitoring/\n",
+		"resources:\n  - ./vendor/katalog/networking/calico\n  - ./vendor/katalog/monitoring/prometheus-operator\n") {
+		files[path] = content
+	}
+	srv := releaseServer(t, files)
+	defer srv.Close()
+	_, cleanup := inTempDir(t)
+	defer cleanup()
+	defer func() { initOpts = initOptions{} }()
+
+	rootCmd.SetArgs([]string{"init", "--version", "v1.6.0", "--modules", "networking", "--index", srv.URL + "/releases.yml"})
+	if err := rootCmd.Execute(); err != nil {
+		t.Fatalf("init error = %v", err)
+	}
+	state, err := readDistributionState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (distributionState{Version: "v1.6.0", Modules: []string{"networking"}}); !reflect.DeepEqual(state, want) {
+		t.Errorf("init state = %+v, want %+v", state, want)
+	}
+
+	rootCmd.SetArgs([]string{"distribution", "upgrade", "--to", "v1.7.0", "--index", srv.URL + "/releases.yml"})
+	if err = rootCmd.Execute(); err != nil {
+		t.Fatalf("upgrade error = %v", err)
+	}
+	furyfile, err := ioutil.ReadFile(furyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(furyfile), "networking: v1.7.0") || strings.Contains(string(furyfile), "monitoring") {
+		t.Errorf("upgraded %s =\n%s\nwant only the networking module upgraded", furyFile, furyfile)
+	}
+	if state, _ = readDistributionState(); !reflect.DeepEqual(state.Modules, []string{"networking"}) || state.Version != "v1.7.0" {
+		t.Errorf("upgrade state = %+v, want the modules picked at init", state)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/sighupio/furyctl/internal/configuration"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+	// the rules of the SARIF reports
+	errorRule   = "invalid-configuration"
+	warningRule = "configuration-warning"
+)
+
+// validationFormats are the formats of the validation reports
+var validationFormats = map[string]func(io.Writer, []validationResult) error{
+	"text":  writeTextReport,
+	"json":  writeJSONReport,
+	"junit": writeJUnitReport,
+	"sarif": writeSARIFReport,
+}
+
+// validationResult contains the errors and the warnings found validating a configuration file
+type validationResult struct {
+	File     string                         `json:"file"`
+	Valid    bool                           `json:"valid"`
+	Errors   configuration.ValidationErrors `json:"errors"`
+	Warnings configuration.ValidationErrors `json:"warnings"`
+}
+
+// validateFiles validates the configuration files of the locations, read as configuration.Open does
+func validateFiles(locations []string, allowUnknownFields bool) []validationResult {
+	results := make([]validationResult, 0, len(locations))
+	for _, location := range locations {
+		var errs, warnings configuration.ValidationErrors
+		r, name, err := configuration.Open(location)
+		if err != nil {
+			name = location
+			errs = configuration.ValidationErrors{{File: location, Line: 1, Column: 1, Message: err.Error()}}
+		} else {
+			errs, warnings = configuration.Check(r, name, configuration.Options{AllowUnknownFields: allowUnknownFields})
+			r.Close()
+		}
+		if errs == nil {
+			errs = configuration.ValidationErrors{}
+		}
+		if warnings == nil {
+			warnings = configuration.ValidationErrors{}
+		}
+		results = append(results, validationResult{File: name, Valid: len(errs) == 0, Errors: errs, Warnings: warnings})
+	}
+	return results
+}
+
+// describeFinding writes a finding as file:line:column: severity: path: message
+func describeFinding(e configuration.ValidationError, severity string) string {
+	path := e.Path
+	if path == "" {
+		path = "<root>"
+	}
+	return fmt.Sprintf("%s:%d:%d: %s: %s: %s", e.File, e.Line, e.Column, severity, path, e.Message)
+}
+
+func writeTextReport(w io.Writer, results []validationResult) error {
+	for _, r := range results {
+		for _, e := range r.Errors {
+			if _, err := fmt.Fprintln(w, describeFinding(e, "error")); err != nil {
+				return err
+			}
+		}
+		for _, e := range r.Warnings {
+			if _, err := fmt.Fprintln(w, describeFinding(e, "warning")); err != nil {
+				return err
+			}
+		}
+		if r.Valid {
+			if _, err := fmt.Fprintf(w, "%s is valid\n", r.File); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeJSONReport(w io.Writer, results []validationResult) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut *junitOutput  `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+type junitOutput struct {
+	Text string `xml:",cdata"`
+}
+
+// writeJUnitReport writes a test case for every configuration file, failing with its errors. The warnings are its output
+func writeJUnitReport(w io.Writer, results []validationResult) error {
+	suite := junitTestSuite{Name: "furyctl config validate", Tests: len(results)}
+	for _, r := range results {
+		c := junitTestCase{ClassName: "furyctl.config", Name: r.File}
+		if !r.Valid {
+			suite.Failures++
+			lines := make([]string, 0, len(r.Errors))
+			for _, e := range r.Errors {
+				lines = append(lines, describeFinding(e, "error"))
+			}
+			c.Failure = &junitFailure{
+				Message: fmt.Sprintf("%s is not valid", r.File),
+				Type:    errorRule,
+				Text:    strings.Join(lines, "\n"),
+			}
+		}
+		if len(r.Warnings) > 0 {
+			lines := make([]string, 0, len(r.Warnings))
+			for _, e := range r.Warnings {
+				lines = append(lines, describeFinding(e, "warning"))
+			}
+			c.SystemOut = &junitOutput{Text: strings.Join(lines, "\n")}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	content, err := xml.MarshalIndent(junitTestSuites{Name: suite.Name, Tests: suite.Tests, Failures: suite.Failures, Suites: []junitTestSuite{suite}}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s%s\n", xml.Header, content)
+	return err
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           *sarifRegion          `json:"region,omitempty"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// writeSARIFReport writes the findings as a SARIF 2.1.0 log, the format of the code scanning annotations
+func writeSARIFReport(w io.Writer, results []validationResult) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "furyctl",
+			Version:        version,
+			InformationURI: "https://github.com/sighupio/furyctl",
+			Rules: []sarifRule{
+				{ID: errorRule, ShortDescription: sarifMessage{Text: "The configuration file is not valid"}},
+				{ID: warningRule, ShortDescription: sarifMessage{Text: "The configuration file is valid but suspicious or deprecated"}},
+			},
+		}},
+		Results: []sarifResult{},
+	}
+	add := func(e configuration.ValidationError, rule, level string) {
+		location := sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: filepath.ToSlash(e.File)}}
+		if e.Line > 0 {
+			location.Region = &sarifRegion{StartLine: e.Line, StartColumn: e.Column}
+		}
+		message := e.Message
+		if e.Path != "" {
+			message = e.Path + ": " + message
+		}
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    rule,
+			Level:     level,
+			Message:   sarifMessage{Text: message},
+			Locations: []sarifLocation{{PhysicalLocation: location}},
+		})
+	}
+	for _, r := range results {
+		for _, e := range r.Errors {
+			add(e, errorRule, "error")
+		}
+		for _, e := range r.Warnings {
+			add(e, warningRule, "warning")
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+
+	"github.com/sighupio/furyctl/internal/configuration"
+)
+
+var sampleResults = []validationResult{
+	{
+		File:  "cluster.yml",
+		Valid: false,
+		Errors: configuration.ValidationErrors{
+			{File: "cluster.yml", Path: "spec.nodePools[0].maxPods", Line: 12, Column: 16, Message: `expected an integer, got "many"`},
+			{File: "production.yml", Path: "spec.nodepools", Line: 3, Column: 3, Message: "unknown field nodepools, did you mean nodePools?"},
+		},
+		Warnings: configuration.ValidationErrors{},
+	},
+	{
+		File:   "bootstrap.yml",
+		Valid:  true,
+		Errors: configuration.ValidationErrors{},
+		Warnings: configuration.ValidationErrors{
+			{File: "bootstrap.yml", Path: "apiVersion", Line: 1, Column: 1, Message: "deprecated apiVersion furyctl.sighup.io/v1alpha1"},
+		},
+	},
+}
+
+func TestTextReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeTextReport(&buf, sampleResults); err != nil {
+		t.Fatal(err)
+	}
+	want := `cluster.yml:12:16: error: spec.nodePools[0].maxPods: expected an integer, got "many"
+production.yml:3:3: error: spec.nodepools: unknown field nodepools, did you mean nodePools?
+bootstrap.yml:1:1: warning: apiVersion: deprecated apiVersion furyctl.sighup.io/v1alpha1
+bootstrap.yml is valid
+`
+	if buf.String() != want {
+		t.Errorf("writeTextReport() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSONReport(&buf, sampleResults); err != nil {
+		t.Fatal(err)
+	}
+	var got []validationResult
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sampleResults) {
+		t.Errorf("writeJSONReport() =\n%s\nwant %v", buf.String(), sampleResults)
+	}
+}
+
+func TestJUnitReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJUnitReport(&buf, sampleResults); err != nil {
+		t.Fatal(err)
+	}
+	var got junitTestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Tests != 2 || got.Failures != 1 || len(got.Suites) != 1 || len(got.Suites[0].Cases) != 2 {
+		t.Fatalf("writeJUnitReport() =\n%s\nwant 2 test cases with 1 failure", buf.String())
+	}
+	failing, passing := got.Suites[0].Cases[0], got.Suites[0].Cases[1]
+	wantFailure := `cluster.yml:12:16: error: spec.nodePools[0].maxPods: expected an integer, got "many"
+production.yml:3:3: error: spec.nodepools: unknown field nodepools, did you mean nodePools?`
+	if failing.Name != "cluster.yml" || failing.Failure == nil || failing.Failure.Text != wantFailure {
+		t.Errorf("unexpected failing test case %+v", failing)
+	}
+	if passing.Failure != nil || passing.SystemOut == nil || passing.SystemOut.Text != "bootstrap.yml:1:1: warning: apiVersion: deprecated apiVersion furyctl.sighup.io/v1alpha1" {
+		t.Errorf("unexpected passing test case %+v", passing)
+	}
+}
+
+func TestSARIFReport(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeSARIFReport(&buf, sampleResults); err != nil {
+		t.Fatal(err)
+	}
+	var got sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Version != "2.1.0" || len(got.Runs) != 1 {
+		t.Fatalf("writeSARIFReport() =\n%s\nwant a SARIF 2.1.0 log with a run", buf.String())
+	}
+	want := []sarifResult{
+		{
+			RuleID:  errorRule,
+			Level:   "error",
+			Message: sarifMessage{Text: `spec.nodePools[0].maxPods: expected an integer, got "many"`},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: "cluster.yml"},
+				Region:           &sarifRegion{StartLine: 12, StartColumn: 16},
+			}}},
+		},
+		{
+			RuleID:  errorRule,
+			Level:   "error",
+			Message: sarifMessage{Text: "spec.nodepools: unknown field nodepools, did you mean nodePools?"},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: "production.yml"},
+				Region:           &sarifRegion{StartLine: 3, StartColumn: 3},
+			}}},
+		},
+		{
+			RuleID:  warningRule,
+			Level:   "warning",
+			Message: sarifMessage{Text: "apiVersion: deprecated apiVersion furyctl.sighup.io/v1alpha1"},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: "bootstrap.yml"},
+				Region:           &sarifRegion{StartLine: 1, StartColumn: 1},
+			}}},
+		},
+	}
+	if !reflect.DeepEqual(got.Runs[0].Results, want) {
+		t.Errorf("writeSARIFReport() results =\n%s\nwant %+v", buf.String(), want)
+	}
+}
@@ -5,16 +5,33 @@
 package cmd
 
 import (
+	"path/filepath"
+
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+var vendorDryRun bool
+var vendorOutput string
+
 func init() {
 	rootCmd.AddCommand(vendorCmd)
+	vendorCmd.AddCommand(vendorListCmd)
 	vendorCmd.PersistentFlags().BoolVarP(&parallel, "parallel", "p", true, "if true enables parallel downloads")
 	vendorCmd.PersistentFlags().BoolVarP(&https, "https", "H", false, "if true downloads using https instead of ssh")
 	vendorCmd.PersistentFlags().StringVarP(&prefix, "prefix", "P", "", "Add filtering on download with prefix, to reduce update scope")
+	vendorCmd.PersistentFlags().StringVar(&overrideFile, "override", defaultOverrideFile, "File with the replace directives pointing packages to local paths")
+	vendorCmd.PersistentFlags().BoolVar(&noOverride, "no-override", false, "if true ignores the override file and downloads every package")
+	vendorCmd.PersistentFlags().BoolVar(&copyOverrides, "copy-overrides", false, "if true copies the overridden packages instead of symlinking them")
+	vendorCmd.PersistentFlags().StringVarP(&vendorOutput, "output", "o", planOutputTable, "Output format of the vendor plan: table, json or tree")
+	vendorCmd.Flags().StringVar(&sshPrivateKey, "ssh-key", "", "Private key used to download packages over ssh")
+	vendorCmd.Flags().BoolVar(&sshAgent, "ssh-agent", false, "if true authenticates the ssh downloads with the running ssh-agent")
+	vendorCmd.Flags().StringVar(&sshKnownHosts, "ssh-known-hosts", "", "known_hosts file used to strictly check the ssh host keys")
+	vendorCmd.Flags().BoolVar(&skipPreflight, "skip-preflight", false, "if true skips the ssh access check done before downloading")
+	vendorCmd.Flags().BoolVar(&noHooks, "no-hooks", false, "if true skips the post download hooks")
+	vendorCmd.Flags().StringVar(&reportFile, "report", "", "Path of the vendor report, defaults to "+vendorReportFile+" inside the vendor folder")
+	vendorCmd.Flags().BoolVar(&vendorDryRun, "dry-run", false, "if true prints the vendor plan without downloading anything")
 }
 
 // vendorCmd represents the vendor command
@@ -23,36 +40,115 @@ var vendorCmd = &cobra.Command{
 	Short: "Download dependencies specified in Furyfile.yml",
 	Long:  "Download dependencies specified in Furyfile.yml",
 	Run: func(cmd *cobra.Command, args []string) {
-		viper.SetConfigType("yml")
-		viper.AddConfigPath(".")
-		viper.SetConfigName(configFile)
-		config := new(Furyconf)
-		if err := viper.ReadInConfig(); err != nil {
-			logrus.Fatalf("Error reading config file, %s", err)
-		}
-		err := viper.Unmarshal(config)
-		if err != nil {
-			logrus.Fatalf("unable to decode into struct, %v", err)
+		config, list := resolveFuryfile()
+
+		if vendorDryRun {
+			err := printPlan(cmd.OutOrStdout(), list, vendorOutput)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			return
 		}
 
-		err = config.Validate()
-		if err != nil {
-			logrus.WithError(err).Error("ERROR VALIDATING")
+		if len(config.replace) > 0 {
+			err := guardOverride(overrideFile, config.VendorFolderName)
+			if err != nil {
+				logrus.Fatal(err)
+			}
+			logrus.Warnf("using local overrides from %s", overrideFile)
+		}
+
+		if !https {
+			sshConf := config.SSH.mergeFlags(sshPrivateKey, sshAgent, cmd.Flags().Changed("ssh-agent"), sshKnownHosts)
+			err := configureSSH(sshConf)
+			if err != nil {
+				logrus.Fatalf("invalid ssh configuration, %v", err)
+			}
+			if !skipPreflight {
+				err = sshPreflight(list)
+				if err != nil {
+					logrus.Fatal(err)
+				}
+			}
 		}
 
-		list, err := config.Parse(prefix)
+		previous := readOverrideState(config.VendorFolderName)
 
+		results, err := download(list)
 		if err != nil {
-			//logrus.Errorln("ERROR PARSING: ", err)
-			logrus.WithError(err).Error("ERROR PARSING")
+			//logrus.Errorln("ERROR DOWNLOADING: ", err)
+			logrus.WithError(err).Error("ERROR DOWNLOADING")
 
 		}
 
-		err = download(list)
+		if reportFile == "" {
+			reportFile = filepath.Join(config.VendorFolderName, vendorReportFile)
+		}
+		err = writeReport(reportFile, results)
 		if err != nil {
-			//logrus.Errorln("ERROR DOWNLOADING: ", err)
-			logrus.WithError(err).Error("ERROR DOWNLOADING")
+			logrus.WithError(err).Error("ERROR WRITING VENDOR REPORT")
+		}
 
+		for _, p := range list {
+			if p.override != "" {
+				logrus.Warnf("package %s is overridden by %s [OVERRIDE]", p.Name, p.override)
+			} else if localPath, ok := previous[p.dir]; ok {
+				logrus.Infof("package %s restored from remote, previously overridden by %s", p.Name, localPath)
+			}
+		}
+
+		err = writeOverrideState(config.VendorFolderName, list)
+		if err != nil {
+			logrus.WithError(err).Error("ERROR WRITING OVERRIDE STATE")
+		}
+	},
+}
+
+// vendorListCmd represents the vendor list command
+var vendorListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the packages specified in Furyfile.yml with their resolved sources",
+	Long:  "List the packages specified in Furyfile.yml with their kind, resolved version, download URL and destination directory, without downloading anything",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		_, list := resolveFuryfile()
+		err := printPlan(cmd.OutOrStdout(), list, vendorOutput)
+		if err != nil {
+			logrus.Fatal(err)
 		}
 	},
 }
+
+// resolveFuryfile reads the Furyfile and the local overrides returning the packages to vendor
+func resolveFuryfile() (*Furyconf, []Package) {
+	viper.SetConfigType("yml")
+	viper.AddConfigPath(".")
+	viper.SetConfigName(configFile)
+	config := new(Furyconf)
+	if err := viper.ReadInConfig(); err != nil {
+		logrus.Fatalf("Error reading config file, %s", err)
+	}
+	err := viper.Unmarshal(config)
+	if err != nil {
+		logrus.Fatalf("unable to decode into struct, %v", err)
+	}
+
+	err = config.Validate()
+	if err != nil {
+		logrus.WithError(err).Error("ERROR VALIDATING")
+	}
+
+	if !noOverride {
+		override, err := loadOverride(overrideFile)
+		if err != nil {
+			logrus.Fatalf("unable to load the override file, %v", err)
+		}
+		config.replace = override.Replace
+	}
+
+	list, err := config.Parse(prefix)
+	if err != nil {
+		logrus.Fatalf("unable to parse the Furyfile, %v", err)
+	}
+	return config, list
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"path"
+	"sort"
+	"strings"
+)
+
+// versionMatch is a pattern of the versions map matching a package name
+type versionMatch struct {
+	pattern     string
+	version     string
+	specificity specificity
+}
+
+// specificity ranks how precisely a pattern describes a package name. Greater values win.
+type specificity struct {
+	exact    bool
+	literals int
+	deep     int
+	globs    int
+	segments int
+	chars    int
+}
+
+func (s specificity) compare(o specificity) int {
+	switch {
+	case s.exact != o.exact:
+		if s.exact {
+			return 1
+		}
+		return -1
+	case s.literals != o.literals:
+		return s.literals - o.literals
+	case s.deep != o.deep:
+		// fewer "**" wildcards means a more specific pattern
+		return o.deep - s.deep
+	case s.globs != o.globs:
+		return o.globs - s.globs
+	case s.segments != o.segments:
+		return s.segments - o.segments
+	default:
+		return s.chars - o.chars
+	}
+}
+
+// Resolve returns the version for the package name, the pattern that supplied it and, when the
+// best match is not unique, the other patterns matching with the same specificity.
+// Keys without wildcards are exact names that also apply to the whole subtree ("aws" is "aws/**").
+// Among the matching patterns the most specific one wins: exact names first, then the pattern
+// with more literal segments, fewer "**" and "*" wildcards and more segments. Remaining ties are broken by
+// the pattern name so the result never depends on the map iteration order.
+func (v VersionPattern) Resolve(name string) (version, pattern string, ambiguous []string) {
+	name = strings.Trim(name, "/")
+	matches := make([]versionMatch, 0, len(v))
+	for p, ver := range v {
+		s, ok := matchVersionPattern(p, name)
+		if !ok {
+			continue
+		}
+		matches = append(matches, versionMatch{pattern: p, version: ver, specificity: s})
+	}
+	if len(matches) == 0 {
+		return "", "", nil
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if c := matches[i].specificity.compare(matches[j].specificity); c != 0 {
+			return c > 0
+		}
+		return matches[i].pattern < matches[j].pattern
+	})
+	best := matches[0]
+	for _, m := range matches[1:] {
+		if m.specificity.compare(best.specificity) != 0 {
+			break
+		}
+		if m.version != best.version {
+			ambiguous = append(ambiguous, m.pattern)
+		}
+	}
+	return best.version, best.pattern, ambiguous
+}
+
+// matchVersionPattern reports if the pattern matches the package name and how specific the match is
+func matchVersionPattern(pattern, name string) (specificity, bool) {
+	trimmed := strings.Trim(pattern, "/")
+	if trimmed == "" {
+		return specificity{}, false
+	}
+	if trimmed == name {
+		return specificity{exact: true}, true
+	}
+	if !strings.ContainsAny(trimmed, "*?[") {
+		trimmed = trimmed + "/**"
+	}
+
+	patternSegments := strings.Split(trimmed, "/")
+	s := specificity{segments: len(patternSegments)}
+	for _, seg := range patternSegments {
+		if seg == "**" {
+			s.deep++
+		} else if strings.ContainsAny(seg, "*?[") {
+			s.globs++
+		} else {
+			s.literals++
+		}
+		s.chars += len(strings.Trim(seg, "*?"))
+	}
+	if !matchSegments(patternSegments, strings.Split(name, "/")) {
+		return specificity{}, false
+	}
+	return s, true
+}
+
+// matchSegments matches path segments, where "**" matches zero or more segments
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(name); i++ {
+			if matchSegments(pattern[1:], name[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	ok, err := path.Match(pattern[0], name[0])
+	if err != nil || !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], name[1:])
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVersionPatternResolve(t *testing.T) {
+	versions := VersionPattern{
+		"aws":           "v1.0.0",
+		"aws/etcd":      "v1.1.0",
+		"aws/*":         "v1.2.0",
+		"dr/**":         "v2.0.0",
+		"dr/velero/*":   "v2.1.0",
+		"monitoring":    "v3.0.0",
+		"logging/**":    "v4.0.0",
+		"logging/*":     "v4.1.0",
+		"opa/*/base":    "v5.0.0",
+		"opa/gatekeep*": "v5.1.0",
+	}
+	tests := []struct {
+		name          string
+		pkg           string
+		wantVersion   string
+		wantPattern   string
+		wantAmbiguous []string
+	}{
+		{name: "exact name wins", pkg: "aws/etcd", wantVersion: "v1.1.0", wantPattern: "aws/etcd"},
+		{name: "single segment glob over subtree", pkg: "aws/aws-vpc", wantVersion: "v1.2.0", wantPattern: "aws/*"},
+		{name: "plain key covers its subtree", pkg: "aws/aws-vpc/nested", wantVersion: "v1.0.0", wantPattern: "aws"},
+		{name: "plain key is not a string prefix", pkg: "awsome", wantVersion: "", wantPattern: ""},
+		{name: "double star", pkg: "dr/restic", wantVersion: "v2.0.0", wantPattern: "dr/**"},
+		{name: "more literal segments win", pkg: "dr/velero/velero-base", wantVersion: "v2.1.0", wantPattern: "dr/velero/*"},
+		{name: "trailing slash", pkg: "monitoring/", wantVersion: "v3.0.0", wantPattern: "monitoring"},
+		{name: "single star over double star", pkg: "logging/fluentd", wantVersion: "v4.1.0", wantPattern: "logging/*"},
+		{
+			name:          "glob in a middle segment",
+			pkg:           "opa/gatekeeper/base",
+			wantVersion:   "v5.0.0",
+			wantPattern:   "opa/*/base",
+			wantAmbiguous: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 20; i++ {
+				version, pattern, ambiguous := versions.Resolve(tt.pkg)
+				if version != tt.wantVersion || pattern != tt.wantPattern {
+					t.Fatalf("Resolve(%q) = %q from %q, want %q from %q", tt.pkg, version, pattern, tt.wantVersion, tt.wantPattern)
+				}
+				if !reflect.DeepEqual(ambiguous, tt.wantAmbiguous) {
+					t.Fatalf("Resolve(%q) ambiguous = %v, want %v", tt.pkg, ambiguous, tt.wantAmbiguous)
+				}
+			}
+		})
+	}
+}
+
+func TestVersionPatternResolveAmbiguous(t *testing.T) {
+	versions := VersionPattern{
+		"ingress/*x": "v1.0.0",
+		"ingress/n*": "v2.0.0",
+	}
+	version, pattern, ambiguous := versions.Resolve("ingress/nginx")
+	if version != "v1.0.0" || pattern != "ingress/*x" {
+		t.Errorf("Resolve() = %q from %q, want v1.0.0 from ingress/*x", version, pattern)
+	}
+	if !reflect.DeepEqual(ambiguous, []string{"ingress/n*"}) {
+		t.Errorf("Resolve() ambiguous = %v, want [ingress/n*]", ambiguous)
+	}
+}
@@ -0,0 +1,279 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// wizard asks the init choices on a terminal
+type wizard struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newWizard(in io.Reader, out io.Writer) *wizard {
+	return &wizard{in: bufio.NewReader(in), out: out}
+}
+
+// run asks every choice, using the given options as defaults
+func (w *wizard) run(available []string, defaults initOptions) (initOptions, error) {
+	o := defaults
+	var err error
+	if len(o.modules) == 0 {
+		o.modules = available
+	}
+	o.modules, err = w.askList("Which modules do you need?", available, o.modules)
+	if err != nil {
+		return o, err
+	}
+	providers := make([]string, 0, len(provisioners)+1)
+	providers = append(providers, "none")
+	for p := range provisioners {
+		providers = append(providers, p)
+	}
+	sort.Strings(providers[1:])
+	provider := o.provider
+	if provider == "" {
+		provider = "none"
+	}
+	provider, err = w.askChoice("Which cloud provider?", providers, provider)
+	if err != nil {
+		return o, err
+	}
+	o.provider, o.bootstrap, o.cluster = "", false, false
+	if provider == "none" {
+		return o, nil
+	}
+	o.provider = provider
+	if provisioners[provider].bootstrap != "" {
+		o.bootstrap, err = w.askBool("Do you want a bootstrap configuration?", defaults.bootstrap || !defaults.cluster)
+		if err != nil {
+			return o, err
+		}
+	}
+	o.cluster, err = w.askBool("Do you want a cluster configuration?", defaults.cluster || !defaults.bootstrap)
+	return o, err
+}
+
+func (w *wizard) ask(question, def string) (string, error) {
+	fmt.Fprintf(w.out, "%s [%s]: ", question, def)
+	answer, err := w.in.ReadString('\n')
+	if err == io.EOF {
+		// the input is over, the remaining questions take the default
+		fmt.Fprintln(w.out)
+	} else if err != nil {
+		return "", err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// askList asks a comma separated subset of the options
+func (w *wizard) askList(question string, options, def []string) ([]string, error) {
+	for {
+		fmt.Fprintf(w.out, "%s (%s)\n", question, strings.Join(options, ", "))
+		answer, err := w.ask("Comma separated list", strings.Join(def, ","))
+		if err != nil {
+			return nil, err
+		}
+		chosen, unknown := splitChoices(answer, options)
+		switch {
+		case len(unknown) > 0:
+			fmt.Fprintf(w.out, "Unknown choices: %s\n", strings.Join(unknown, ", "))
+		case len(chosen) == 0:
+			fmt.Fprintf(w.out, "Choose at least one\n")
+		default:
+			return chosen, nil
+		}
+	}
+}
+
+// askChoice asks one of the options
+func (w *wizard) askChoice(question string, options []string, def string) (string, error) {
+	for {
+		answer, err := w.ask(fmt.Sprintf("%s (%s)", question, strings.Join(options, ", ")), def)
+		if err != nil {
+			return "", err
+		}
+		for _, o := range options {
+			if answer == o {
+				return answer, nil
+			}
+		}
+		fmt.Fprintf(w.out, "Unknown choice: %s\n", answer)
+	}
+}
+
+// askBool asks a yes or no question
+func (w *wizard) askBool(question string, def bool) (bool, error) {
+	d := "n"
+	if def {
+		d = "y"
+	}
+	for {
+		answer, err := w.ask(question+" (y/n)", d)
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintf(w.out, "Please answer y or n\n")
+	}
+}
+
+func splitChoices(answer string, options []string) (chosen, unknown []string) {
+	valid := map[string]bool{}
+	for _, o := range options {
+		valid[o] = true
+	}
+	for _, c := range strings.Split(answer, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		if valid[c] {
+			chosen = append(chosen, c)
+		} else {
+			unknown = append(unknown, c)
+		}
+	}
+	return chosen, unknown
+}
+
+// moduleOf returns the distribution module of a package name, e.g. networking for networking/calico
+func moduleOf(name string) string {
+	return strings.SplitN(strings.Trim(name, "/"), "/", 2)[0]
+}
+
+// availableModules returns the distribution modules listed in a release Furyfile
+func availableModules(furyfile []byte) ([]string, error) {
+	var f struct {
+		Versions yaml.MapSlice `yaml:"versions"`
+		Bases    []Package     `yaml:"bases"`
+	}
+	if err := yaml.Unmarshal(furyfile, &f); err != nil {
+		return nil, fmt.Errorf("unable to decode the release %s: %v", furyFile, err)
+	}
+	seen := map[string]bool{}
+	modules := []string{}
+	add := func(name string) {
+		m := moduleOf(name)
+		if m != "" && !seen[m] {
+			seen[m] = true
+			modules = append(modules, m)
+		}
+	}
+	for _, v := range f.Versions {
+		add(fmt.Sprint(v.Key))
+	}
+	for _, b := range f.Bases {
+		add(b.Name)
+	}
+	return modules, nil
+}
+
+// filterRelease keeps only the selected distribution modules in the release Furyfile.yml and kustomization.yaml
+func filterRelease(release map[string][]byte, modules []string) (map[string][]byte, error) {
+	furyfile, err := filterFuryfile(release[furyFile], modules)
+	if err != nil {
+		return nil, err
+	}
+	kustomization, err := filterKustomization(release[kustomizationFile], modules)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{furyFile: furyfile, kustomizationFile: kustomization}, nil
+}
+
+// filterFuryfile keeps only the versions, bases and modules of the selected distribution modules.
+// The release Furyfile is returned untouched when no module is selected
+func filterFuryfile(furyfile []byte, modules []string) ([]byte, error) {
+	if len(modules) == 0 {
+		return furyfile, nil
+	}
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(furyfile, &doc); err != nil {
+		return nil, fmt.Errorf("unable to decode the release %s: %v", furyFile, err)
+	}
+	keep := selected(modules)
+	for i, item := range doc {
+		switch item.Key {
+		case "versions":
+			versions, _ := item.Value.(yaml.MapSlice)
+			filtered := yaml.MapSlice{}
+			for _, v := range versions {
+				if keep(fmt.Sprint(v.Key)) {
+					filtered = append(filtered, v)
+				}
+			}
+			doc[i].Value = filtered
+		case "roles", "modules", "bases":
+			packages, _ := item.Value.([]interface{})
+			filtered := []interface{}{}
+			for _, p := range packages {
+				entry, _ := p.(yaml.MapSlice)
+				for _, field := range entry {
+					if field.Key == "name" && keep(fmt.Sprint(field.Value)) {
+						filtered = append(filtered, p)
+					}
+				}
+			}
+			doc[i].Value = filtered
+		}
+	}
+	return yaml.Marshal(doc)
+}
+
+// filterKustomization keeps only the resources of the selected distribution modules
+func filterKustomization(kustomization []byte, modules []string) ([]byte, error) {
+	if len(modules) == 0 {
+		return kustomization, nil
+	}
+	var doc yaml.MapSlice
+	if err := yaml.Unmarshal(kustomization, &doc); err != nil {
+		return nil, fmt.Errorf("unable to decode the release %s: %v", kustomizationFile, err)
+	}
+	keep := selected(modules)
+	for i, item := range doc {
+		if item.Key != "resources" && item.Key != "bases" {
+			continue
+		}
+		resources, _ := item.Value.([]interface{})
+		filtered := []interface{}{}
+		for _, r := range resources {
+			path := fmt.Sprint(r)
+			idx := strings.Index(path, "katalog/")
+			if idx < 0 || keep(path[idx+len("katalog/"):]) {
+				filtered = append(filtered, r)
+			}
+		}
+		doc[i].Value = filtered
+	}
+	return yaml.Marshal(doc)
+}
+
+func selected(modules []string) func(name string) bool {
+	set := map[string]bool{}
+	for _, m := range modules {
+		set[m] = true
+	}
+	return func(name string) bool {
+		return set[moduleOf(name)]
+	}
+}
@@ -38,7 +38,14 @@ resource "local_file" "public" {
   directory_permission = "0700"
 }
 
+# the custom attributes are global to vCenter, shared by the clusters: they are looked up, never owned by a cluster
+data "vsphere_custom_attribute" "tags" {
+  for_each = var.tags
+  name     = each.key
+}
+
 locals {
+  custom_attributes   = { for key, value in var.tags : data.vsphere_custom_attribute.tags[key].id => value }
   tmp_ssh_public_keys = [for pub in var.ssh_public_keys : file(pub)]
   ssh_public_keys     = concat(local.tmp_ssh_public_keys, [tls_private_key.fury.public_key_openssh])
 }
@@ -0,0 +1,11 @@
+/**
+ * Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+ * Use of this source code is governed by a BSD-style
+ * license that can be found in the LICENSE file.
+ */
+
+# Written by furyctl only when the project has labels: the custom_attributes input requires a fury vsphere module
+# declaring it, the projects without labels keep working with any module release
+module "fury" {
+  custom_attributes = local.custom_attributes
+}
@@ -73,6 +73,12 @@ variable "env" {
   description = "Cluster environment"
 }
 
+variable "tags" {
+  type        = map(string)
+  description = "The custom attributes to set on all the virtual machines"
+  default     = {}
+}
+
 variable "datacenter" {
   type        = string
   description = "Datacenter Name as seen in vCenter"
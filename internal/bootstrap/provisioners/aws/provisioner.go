@@ -5,17 +5,15 @@
 package aws
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"strings"
 
 	"github.com/gobuffalo/packr/v2"
 	"github.com/hashicorp/terraform-exec/tfexec"
 	cfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
 	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
 
 	log "github.com/sirupsen/logrus"
 )
@@ -129,55 +127,9 @@ const (
 	projectPath = "../../../../data/provisioners/bootstrap/aws"
 )
 
-func (d AWS) createVarFile() (err error) {
-	var buffer bytes.Buffer
-	spec := d.config.Spec.(cfg.AWS)
-
-	buffer.WriteString(fmt.Sprintf("name = \"%v\"\n", d.config.Metadata.Name))
-	buffer.WriteString(fmt.Sprintf("network_cidr = \"%v\"\n", spec.NetworkCIDR))
-	buffer.WriteString(fmt.Sprintf("public_subnetwork_cidrs = [\"%v\"]\n", strings.Join(spec.PublicSubnetsCIDRs, "\",\"")))
-	buffer.WriteString(fmt.Sprintf("private_subnetwork_cidrs = [\"%v\"]\n", strings.Join(spec.PrivateSubnetsCIDRs, "\",\"")))
-	buffer.WriteString(fmt.Sprintf("vpn_subnetwork_cidr = \"%v\"\n", spec.VPN.SubnetCIDR))
-	if len(spec.Tags) > 0 {
-		var tags []byte
-		tags, err = json.Marshal(spec.Tags)
-		if err != nil {
-			return err
-		}
-		buffer.WriteString(fmt.Sprintf("tags = %v\n", string(tags)))
-	}
-	buffer.WriteString(fmt.Sprintf("vpn_instances = %v\n", spec.VPN.Instances))
-	if spec.VPN.Port != 0 {
-		buffer.WriteString(fmt.Sprintf("vpn_port = %v\n", spec.VPN.Port))
-	}
-	if spec.VPN.InstanceType != "" {
-		buffer.WriteString(fmt.Sprintf("vpn_instance_type = \"%v\"\n", spec.VPN.InstanceType))
-	}
-	if spec.VPN.DiskSize != 0 {
-		buffer.WriteString(fmt.Sprintf("vpn_instance_disk_size = %v\n", spec.VPN.DiskSize))
-	}
-	if spec.VPN.OperatorName != "" {
-		buffer.WriteString(fmt.Sprintf("vpn_operator_name = \"%v\"\n", spec.VPN.OperatorName))
-	}
-	if spec.VPN.DHParamsBits != 0 {
-		buffer.WriteString(fmt.Sprintf("vpn_dhparams_bits = %v\n", spec.VPN.DHParamsBits))
-	}
-	if len(spec.VPN.OperatorCIDRs) != 0 {
-		buffer.WriteString(fmt.Sprintf("vpn_operator_cidrs = [\"%v\"]\n", strings.Join(spec.VPN.OperatorCIDRs, "\",\"")))
-	}
-	if len(spec.VPN.SSHUsers) != 0 {
-		buffer.WriteString(fmt.Sprintf("vpn_ssh_users = [\"%v\"]\n", strings.Join(spec.VPN.SSHUsers, "\",\"")))
-	}
-
-	err = ioutil.WriteFile(fmt.Sprintf("%v/aws.tfvars", d.terraform.WorkingDir()), buffer.Bytes(), 0600)
-	if err != nil {
-		return err
-	}
-	err = d.terraform.FormatWrite(context.Background(), tfexec.Dir(fmt.Sprintf("%v/aws.tfvars", d.terraform.WorkingDir())))
-	if err != nil {
-		return err
-	}
-	return nil
+// createVarFile writes the variables of the configuration, returning the path of the var file
+func (d AWS) createVarFile() (string, error) {
+	return terraform.WriteVarFile(d.terraform.WorkingDir(), "aws", newVariables(d.config))
 }
 
 // New instantiates a new AWS provisioner
@@ -223,11 +175,11 @@ func (d AWS) Prepare() (err error) {
 // Plan runs a dry run execution
 func (d AWS) Plan() (err error) {
 	log.Info("[DRYRUN] Updating AWS Bootstrap project")
-	err = d.createVarFile()
+	varFile, err := d.createVarFile()
 	if err != nil {
 		return err
 	}
-	changes, err := d.terraform.Plan(context.Background(), tfexec.VarFile(fmt.Sprintf("%v/aws.tfvars", d.terraform.WorkingDir())))
+	changes, err := d.terraform.Plan(context.Background(), tfexec.VarFile(varFile))
 	if err != nil {
 		log.Fatalf("[DRYRUN] Something went wrong while updating aws. %v", err)
 		return err
@@ -245,12 +197,12 @@ func (d AWS) Plan() (err error) {
 // Update runs terraform apply in the project
 func (d AWS) Update() (string, error) {
 	log.Info("Updating AWS Bootstrap project")
-	err := d.createVarFile()
+	varFile, err := d.createVarFile()
 	if err != nil {
 		return "", err
 	}
 
-	err = d.terraform.Apply(context.Background(), tfexec.VarFile(fmt.Sprintf("%v/aws.tfvars", d.terraform.WorkingDir())))
+	err = d.terraform.Apply(context.Background(), tfexec.VarFile(varFile))
 	if err != nil {
 		log.Fatalf("Something went wrong while updating aws. %v", err)
 		return "", err
@@ -263,12 +215,12 @@ func (d AWS) Update() (string, error) {
 // Destroy runs terraform destroy in the project
 func (d AWS) Destroy() (err error) {
 	log.Info("Destroying AWS Bootstrap project")
-	err = d.createVarFile()
+	varFile, err := d.createVarFile()
 	if err != nil {
 		return err
 	}
 
-	err = d.terraform.Destroy(context.Background(), tfexec.VarFile(fmt.Sprintf("%v/aws.tfvars", d.terraform.WorkingDir())))
+	err = d.terraform.Destroy(context.Background(), tfexec.VarFile(varFile))
 	if err != nil {
 		log.Fatalf("Something went wrong while destroying AWS Bootstrap project. %v", err)
 		return err
@@ -0,0 +1,49 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package aws
+
+import (
+	cfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+// variables are the terraform variables of the AWS project
+type variables struct {
+	Name                   string            `json:"name"`
+	NetworkCIDR            string            `json:"network_cidr"`
+	PublicSubnetworkCIDRs  []string          `json:"public_subnetwork_cidrs"`
+	PrivateSubnetworkCIDRs []string          `json:"private_subnetwork_cidrs"`
+	VPNSubnetworkCIDR      string            `json:"vpn_subnetwork_cidr"`
+	Tags                   map[string]string `json:"tags,omitempty"`
+	VPNInstances           int               `json:"vpn_instances"`
+	VPNPort                int               `json:"vpn_port,omitempty"`
+	VPNInstanceType        string            `json:"vpn_instance_type,omitempty"`
+	VPNInstanceDiskSize    int               `json:"vpn_instance_disk_size,omitempty"`
+	VPNOperatorName        string            `json:"vpn_operator_name,omitempty"`
+	VPNDHParamsBits        int               `json:"vpn_dhparams_bits,omitempty"`
+	VPNOperatorCIDRs       []string          `json:"vpn_operator_cidrs,omitempty"`
+	VPNSSHUsers            []string          `json:"vpn_ssh_users,omitempty"`
+}
+
+func newVariables(config *configuration.Configuration) variables {
+	spec := config.Spec.(cfg.AWS)
+	return variables{
+		Name:                   config.Metadata.Name,
+		NetworkCIDR:            spec.NetworkCIDR,
+		PublicSubnetworkCIDRs:  terraform.Strings(spec.PublicSubnetsCIDRs),
+		PrivateSubnetworkCIDRs: terraform.Strings(spec.PrivateSubnetsCIDRs),
+		VPNSubnetworkCIDR:      spec.VPN.SubnetCIDR,
+		Tags:                   configuration.ResourceTags(config),
+		VPNInstances:           spec.VPN.Instances,
+		VPNPort:                spec.VPN.Port,
+		VPNInstanceType:        spec.VPN.InstanceType,
+		VPNInstanceDiskSize:    spec.VPN.DiskSize,
+		VPNOperatorName:        spec.VPN.OperatorName,
+		VPNDHParamsBits:        spec.VPN.DHParamsBits,
+		VPNOperatorCIDRs:       spec.VPN.OperatorCIDRs,
+		VPNSSHUsers:            spec.VPN.SSHUsers,
+	}
+}
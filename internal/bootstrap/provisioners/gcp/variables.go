@@ -0,0 +1,55 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	cfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+// variables are the terraform variables of the GCP project
+type variables struct {
+	Name                         string            `json:"name"`
+	PublicSubnetworkCIDRs        []string          `json:"public_subnetwork_cidrs"`
+	PrivateSubnetworkCIDRs       []string          `json:"private_subnetwork_cidrs"`
+	ClusterControlPlaneCIDRBlock string            `json:"cluster_control_plane_cidr_block,omitempty"`
+	ClusterSubnetworkCIDR        string            `json:"cluster_subnetwork_cidr"`
+	ClusterPodSubnetworkCIDR     string            `json:"cluster_pod_subnetwork_cidr"`
+	ClusterServiceSubnetworkCIDR string            `json:"cluster_service_subnetwork_cidr"`
+	VPNSubnetworkCIDR            string            `json:"vpn_subnetwork_cidr"`
+	Tags                         map[string]string `json:"tags,omitempty"`
+	VPNInstances                 int               `json:"vpn_instances"`
+	VPNPort                      int               `json:"vpn_port,omitempty"`
+	VPNInstanceType              string            `json:"vpn_instance_type,omitempty"`
+	VPNInstanceDiskSize          int               `json:"vpn_instance_disk_size,omitempty"`
+	VPNOperatorName              string            `json:"vpn_operator_name,omitempty"`
+	VPNDHParamsBits              int               `json:"vpn_dhparams_bits,omitempty"`
+	VPNOperatorCIDRs             []string          `json:"vpn_operator_cidrs,omitempty"`
+	VPNSSHUsers                  []string          `json:"vpn_ssh_users,omitempty"`
+}
+
+func newVariables(config *configuration.Configuration) variables {
+	spec := config.Spec.(cfg.GCP)
+	return variables{
+		Name:                         config.Metadata.Name,
+		PublicSubnetworkCIDRs:        terraform.Strings(spec.PublicSubnetsCIDRs),
+		PrivateSubnetworkCIDRs:       terraform.Strings(spec.PrivateSubnetsCIDRs),
+		ClusterControlPlaneCIDRBlock: spec.ClusterNetwork.ControlPlaneCIDR,
+		ClusterSubnetworkCIDR:        spec.ClusterNetwork.SubnetworkCIDR,
+		ClusterPodSubnetworkCIDR:     spec.ClusterNetwork.PodSubnetworkCIDR,
+		ClusterServiceSubnetworkCIDR: spec.ClusterNetwork.ServiceSubnetworkCIDR,
+		VPNSubnetworkCIDR:            spec.VPN.SubnetCIDR,
+		Tags:                         configuration.ResourceTags(config),
+		VPNInstances:                 spec.VPN.Instances,
+		VPNPort:                      spec.VPN.Port,
+		VPNInstanceType:              spec.VPN.InstanceType,
+		VPNInstanceDiskSize:          spec.VPN.DiskSize,
+		VPNOperatorName:              spec.VPN.OperatorName,
+		VPNDHParamsBits:              spec.VPN.DHParamsBits,
+		VPNOperatorCIDRs:             spec.VPN.OperatorCIDRs,
+		VPNSSHUsers:                  spec.VPN.SSHUsers,
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gcp
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestVarFile(t *testing.T) {
+	config := &configuration.Configuration{
+		Kind:        "Bootstrap",
+		Provisioner: "gcp",
+		Metadata:    configuration.Metadata{Name: "demo", Labels: map[string]interface{}{"Team": "Platform", "cost-center": 42}},
+		Spec: cfg.GCP{
+			PublicSubnetsCIDRs:  []string{"10.0.1.0/24"},
+			PrivateSubnetsCIDRs: []string{"10.0.101.0/24"},
+			ClusterNetwork: cfg.GCPClusterNetwork{
+				ControlPlaneCIDR:      "10.0.0.0/28",
+				SubnetworkCIDR:        "10.1.0.0/16",
+				PodSubnetworkCIDR:     "10.2.0.0/16",
+				ServiceSubnetworkCIDR: "10.3.0.0/16",
+			},
+			VPN: cfg.GCPVPN{
+				Instances:  1,
+				SubnetCIDR: "192.168.200.0/24",
+				SSHUsers:   []string{"github-user"},
+			},
+			Tags: map[string]string{"path": `C:\demo`},
+		},
+	}
+	testVarFile(t, "gcp", newVariables(config))
+}
+
+// testVarFile writes the variables of a provisioner, comparing them with the golden file of the testdata
+func testVarFile(t *testing.T, name string, variables interface{}) {
+	dir, err := ioutil.TempDir("", "furyctl-tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := terraform.WriteVarFile(dir, name, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", name+".tfvars.json")
+	if *update {
+		if err = ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s.tfvars.json =\n%s\nwant\n%s", name, got, want)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package eks
+
+import (
+	cfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+// variables are the terraform variables of the EKS project
+type variables struct {
+	ClusterName    string            `json:"cluster_name"`
+	ClusterVersion string            `json:"cluster_version"`
+	Network        string            `json:"network"`
+	SubNetworks    []string          `json:"subnetworks"`
+	DMZCIDRRange   []string          `json:"dmz_cidr_range"`
+	SSHPublicKey   string            `json:"ssh_public_key"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	MapAccounts    []string          `json:"eks_map_accounts,omitempty"`
+	MapUsers       []mapUser         `json:"eks_map_users,omitempty"`
+	MapRoles       []mapRole         `json:"eks_map_roles,omitempty"`
+	NodePools      []nodePool        `json:"node_pools,omitempty"`
+}
+
+type mapUser struct {
+	Groups   []string `json:"groups"`
+	Username string   `json:"username"`
+	UserARN  string   `json:"userarn"`
+}
+
+type mapRole struct {
+	Groups   []string `json:"groups"`
+	Username string   `json:"username"`
+	RoleARN  string   `json:"rolearn"`
+}
+
+type nodePool struct {
+	Name                    string            `json:"name"`
+	Version                 *string           `json:"version"` // null uses the version of the control plane
+	SpotInstance            bool              `json:"spot_instance"`
+	MinSize                 int               `json:"min_size"`
+	MaxSize                 int               `json:"max_size"`
+	InstanceType            string            `json:"instance_type"`
+	OS                      string            `json:"os,omitempty"`
+	TargetGroups            []string          `json:"eks_target_group_arns,omitempty"`
+	MaxPods                 int               `json:"max_pods,omitempty"`
+	VolumeSize              int               `json:"volume_size"`
+	AdditionalFirewallRules []firewallRule    `json:"additional_firewall_rules"`
+	SubNetworks             []string          `json:"subnetworks"` // null uses the subnetworks of the cluster
+	Labels                  map[string]string `json:"labels"`
+	Taints                  []string          `json:"taints"`
+	Tags                    map[string]string `json:"tags"`
+}
+
+type firewallRule struct {
+	Name      string            `json:"name"`
+	Direction string            `json:"direction"`
+	CIDRBlock string            `json:"cidr_block"`
+	Protocol  string            `json:"protocol"`
+	Ports     string            `json:"ports"`
+	Tags      map[string]string `json:"tags"`
+}
+
+func newVariables(config *configuration.Configuration) variables {
+	spec := config.Spec.(cfg.EKS)
+	v := variables{
+		ClusterName:    config.Metadata.Name,
+		ClusterVersion: spec.Version,
+		Network:        spec.Network,
+		SubNetworks:    terraform.Strings(spec.SubNetworks),
+		DMZCIDRRange:   terraform.Strings(spec.DMZCIDRRange.Values),
+		SSHPublicKey:   spec.SSHPublicKey,
+		Tags:           configuration.ResourceTags(config),
+		MapAccounts:    spec.Auth.AdditionalAccounts,
+	}
+	for _, user := range spec.Auth.Users {
+		v.MapUsers = append(v.MapUsers, mapUser{Groups: terraform.Strings(user.Groups), Username: user.Username, UserARN: user.UserARN})
+	}
+	for _, role := range spec.Auth.Roles {
+		v.MapRoles = append(v.MapRoles, mapRole{Groups: terraform.Strings(role.Groups), Username: role.Username, RoleARN: role.RoleARN})
+	}
+	for _, np := range spec.NodePools {
+		pool := nodePool{
+			Name:                    np.Name,
+			SpotInstance:            np.SpotInstance,
+			MinSize:                 np.MinSize,
+			MaxSize:                 np.MaxSize,
+			InstanceType:            np.InstanceType,
+			OS:                      np.OS,
+			TargetGroups:            np.TargetGroups,
+			MaxPods:                 np.MaxPods,
+			VolumeSize:              np.VolumeSize,
+			AdditionalFirewallRules: []firewallRule{},
+			Labels:                  terraform.StringMap(np.Labels),
+			Taints:                  terraform.Strings(np.Taints),
+			Tags:                    terraform.StringMap(np.Tags),
+		}
+		if np.Version != "" {
+			version := np.Version
+			pool.Version = &version
+		}
+		if len(np.SubNetworks) > 0 {
+			pool.SubNetworks = np.SubNetworks
+		}
+		for _, rule := range np.AdditionalFirewallRules {
+			pool.AdditionalFirewallRules = append(pool.AdditionalFirewallRules, firewallRule{
+				Name:      rule.Name,
+				Direction: rule.Direction,
+				CIDRBlock: rule.CIDRBlock,
+				Protocol:  rule.Protocol,
+				Ports:     rule.Ports,
+				Tags:      terraform.StringMap(rule.Tags),
+			})
+		}
+		v.NodePools = append(v.NodePools, pool)
+	}
+	return v
+}
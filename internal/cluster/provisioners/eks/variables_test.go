@@ -0,0 +1,98 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package eks
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestVarFile(t *testing.T) {
+	config := &configuration.Configuration{
+		Kind:        "Cluster",
+		Provisioner: "eks",
+		Metadata:    configuration.Metadata{Name: "demo", Labels: map[string]interface{}{"team": "platform"}},
+		Spec: cfg.EKS{
+			Version:      "1.21",
+			Network:      "vpc-1",
+			SubNetworks:  []string{"subnet-1", "subnet-2"},
+			DMZCIDRRange: cfg.DMZCIDRRange{Values: []string{"10.0.0.0/16"}},
+			SSHPublicKey: "ssh-rsa AAAA \"quoted\" comment",
+			Tags:         map[string]string{"env": "production"},
+			Auth: cfg.EKSAuth{
+				AdditionalAccounts: []string{"123456789012"},
+				Users:              []cfg.EKSAuthData{{Username: "jane", Groups: []string{"system:masters"}, UserARN: "arn:aws:iam::123456789012:user/jane"}},
+				Roles:              []cfg.EKSAuthData{{Username: "ci", RoleARN: "arn:aws:iam::123456789012:role/ci"}},
+			},
+			NodePools: []cfg.EKSNodePool{
+				{
+					Name:         "infra",
+					Version:      "1.21",
+					MinSize:      1,
+					MaxSize:      3,
+					InstanceType: "t3.large",
+					TargetGroups: []string{"arn:aws:elasticloadbalancing:eu-west-1:123456789012:targetgroup/infra"},
+					MaxPods:      110,
+					VolumeSize:   50,
+					Labels:       map[string]string{"description": `the "infra" nodes`},
+					Taints:       []string{"node.kubernetes.io/role=infra:NoSchedule"},
+					AdditionalFirewallRules: []cfg.EKSNodePoolFwRule{
+						{Name: "debug", Direction: "ingress", CIDRBlock: "10.0.0.0/8", Protocol: "TCP", Ports: "22-22"},
+					},
+				},
+				{
+					Name:         "app",
+					MaxSize:      10,
+					InstanceType: "m5.xlarge",
+					OS:           `ami-\1`,
+					SpotInstance: true,
+					VolumeSize:   100,
+					SubNetworks:  []string{"subnet-3"},
+				},
+			},
+		},
+	}
+	testVarFile(t, "eks", newVariables(config))
+}
+
+// testVarFile writes the variables of a provisioner, comparing them with the golden file of the testdata
+func testVarFile(t *testing.T, name string, variables interface{}) {
+	dir, err := ioutil.TempDir("", "furyctl-tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := terraform.WriteVarFile(dir, name, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", name+".tfvars.json")
+	if *update {
+		if err = ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s.tfvars.json =\n%s\nwant\n%s", name, got, want)
+	}
+}
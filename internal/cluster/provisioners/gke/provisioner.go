@@ -5,19 +5,16 @@
 package gke
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"strings"
 
 	"github.com/gobuffalo/packr/v2"
 	"github.com/hashicorp/terraform-exec/tfexec"
 	log "github.com/sirupsen/logrus"
 
-	cfg "github.com/sighupio/furyctl/internal/cluster/configuration"
 	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
 )
 
 // InitMessage return a custom provisioner message the user will see once the cluster is ready to be updated
@@ -102,132 +99,9 @@ const (
 	projectPath = "../../../../data/provisioners/cluster/gke"
 )
 
-func (e GKE) createVarFile() (err error) {
-	var buffer bytes.Buffer
-	spec := e.config.Spec.(cfg.GKE)
-	buffer.WriteString(fmt.Sprintf("cluster_name = \"%v\"\n", e.config.Metadata.Name))
-	buffer.WriteString(fmt.Sprintf("cluster_version = \"%v\"\n", spec.Version))
-	buffer.WriteString(fmt.Sprintf("network = \"%v\"\n", spec.Network))
-	buffer.WriteString(fmt.Sprintf("subnetworks = [\"%v\"]\n", strings.Join(spec.SubNetworks, "\",\"")))
-	buffer.WriteString(fmt.Sprintf("dmz_cidr_range = [\"%v\"]\n", strings.Join(spec.DMZCIDRRange.Values, "\",\"")))
-	buffer.WriteString(fmt.Sprintf("ssh_public_key = \"%v\"\n", spec.SSHPublicKey))
-	if len(spec.Tags) > 0 {
-		var tags []byte
-		tags, err = json.Marshal(spec.Tags)
-		if err != nil {
-			return err
-		}
-		buffer.WriteString(fmt.Sprintf("tags = %v\n", string(tags)))
-	}
-
-	if len(spec.NodePools) > 0 {
-		buffer.WriteString("node_pools = [\n")
-		for _, np := range spec.NodePools {
-			buffer.WriteString("{\n")
-			buffer.WriteString(fmt.Sprintf("name = \"%v\"\n", np.Name))
-			buffer.WriteString(fmt.Sprintf("version = \"%v\"\n", np.Version))
-			buffer.WriteString(fmt.Sprintf("min_size = %v\n", np.MinSize))
-			buffer.WriteString(fmt.Sprintf("max_size = %v\n", np.MaxSize))
-			buffer.WriteString(fmt.Sprintf("instance_type = \"%v\"\n", np.InstanceType))
-			if np.OS != "" {
-				buffer.WriteString(fmt.Sprintf("os = \"%v\"\n", np.OS))
-			}
-			if np.MaxPods > 0 {
-				buffer.WriteString(fmt.Sprintf("max_pods = %v\n", np.MaxPods))
-			}
-			buffer.WriteString(fmt.Sprintf("volume_size = %v\n", np.VolumeSize))
-			buffer.WriteString(fmt.Sprintf("spot_instance = %v\n", np.SpotInstance))
-
-			if len(np.SubNetworks) > 0 {
-				buffer.WriteString(fmt.Sprintf("subnetworks = [\"%v\"]\n", strings.Join(np.SubNetworks, "\",\"")))
-			} else {
-				buffer.WriteString("subnetworks = []\n")
-			}
-			if len(np.Labels) > 0 {
-				var labels []byte
-				labels, err = json.Marshal(np.Labels)
-				if err != nil {
-					return err
-				}
-				buffer.WriteString(fmt.Sprintf("labels = %v\n", string(labels)))
-			} else {
-				buffer.WriteString("labels = {}\n")
-			}
-
-			if len(np.Taints) > 0 {
-				buffer.WriteString(fmt.Sprintf("taints = [\"%v\"]\n", strings.Join(np.Taints, "\",\"")))
-			} else {
-				buffer.WriteString("taints = []\n")
-			}
-
-			if len(np.Tags) > 0 {
-				var tags []byte
-				tags, err = json.Marshal(np.Tags)
-				if err != nil {
-					return err
-				}
-				buffer.WriteString(fmt.Sprintf("tags = %v\n", string(tags)))
-			} else {
-				buffer.WriteString("tags = {}\n")
-			}
-
-			if len(np.AdditionalFirewallRules) > 0 {
-
-				buffer.WriteString("additional_firewall_rules = [\n")
-				for _, fwRule := range np.AdditionalFirewallRules {
-
-					fwRuleTags := "{}"
-					if len(fwRule.Tags) > 0 {
-						var tags []byte
-						tags, err = json.Marshal(fwRule.Tags)
-						if err != nil {
-							return err
-						}
-						fwRuleTags = string(tags)
-					}
-
-					buffer.WriteString(
-						fmt.Sprintf(
-							`{
-			name = "%v"
-			direction = "%v"
-			cidr_block = "%v"
-			protocol = "%v"
-			ports = "%v"
-			tags = %v
-		},
-		`, fwRule.Name, fwRule.Direction, fwRule.CIDRBlock, fwRule.Protocol, fwRule.Ports, fwRuleTags,
-						),
-					)
-				}
-				buffer.WriteString("]\n")
-			} else {
-				buffer.WriteString("additional_firewall_rules = []\n")
-			}
-
-			buffer.WriteString("},\n")
-		}
-		buffer.WriteString("]\n")
-	}
-
-	buffer.WriteString(fmt.Sprintf("gke_network_project_id = \"%v\"\n", spec.NetworkProjectID))
-	buffer.WriteString(fmt.Sprintf("gke_master_ipv4_cidr_block = \"%v\"\n", spec.ControlPlaneCIDR))
-	buffer.WriteString(fmt.Sprintf("gke_add_additional_firewall_rules = %v\n", spec.AdditionalFirewallRules))
-	buffer.WriteString(fmt.Sprintf("gke_add_cluster_firewall_rules = %v\n", spec.AdditionalClusterFirewallRules))
-	buffer.WriteString(fmt.Sprintf("gke_disable_default_snat = %v\n", spec.DisableDefaultSNAT))
-
-	err = ioutil.WriteFile(fmt.Sprintf("%v/gke.tfvars", e.terraform.WorkingDir()), buffer.Bytes(), 0600)
-	if err != nil {
-		return err
-	}
-	err = e.terraform.FormatWrite(
-		context.Background(),
-		tfexec.Dir(fmt.Sprintf("%v/gke.tfvars", e.terraform.WorkingDir())),
-	)
-	if err != nil {
-		return err
-	}
-	return nil
+// createVarFile writes the variables of the configuration, returning the path of the var file
+func (e GKE) createVarFile() (string, error) {
+	return terraform.WriteVarFile(e.terraform.WorkingDir(), "gke", newVariables(e.config))
 }
 
 // New instantiates a new GKE provisioner
@@ -268,14 +142,14 @@ func (e GKE) TerraformFiles() []string {
 // Plan runs a dry run execution
 func (e GKE) Plan() (err error) {
 	log.Info("[DRYRUN] Updating GKE Cluster project")
-	err = e.createVarFile()
+	varFile, err := e.createVarFile()
 	if err != nil {
 		return err
 	}
 	var changes bool
 	changes, err = e.terraform.Plan(
 		context.Background(),
-		tfexec.VarFile(fmt.Sprintf("%v/gke.tfvars", e.terraform.WorkingDir())),
+		tfexec.VarFile(varFile),
 	)
 	if err != nil {
 		log.Fatalf("[DRYRUN] Something went wrong while updating gke. %v", err)
@@ -298,13 +172,13 @@ func (e GKE) Prepare() (err error) {
 // Update runs terraform apply in the project
 func (e GKE) Update() (string, error) {
 	log.Info("Updating GKE project")
-	err := e.createVarFile()
+	varFile, err := e.createVarFile()
 	if err != nil {
 		return "", err
 	}
 	err = e.terraform.Apply(
 		context.Background(),
-		tfexec.VarFile(fmt.Sprintf("%v/gke.tfvars", e.terraform.WorkingDir())),
+		tfexec.VarFile(varFile),
 	)
 	if err != nil {
 		log.Fatalf("Something went wrong while updating gke. %v", err)
@@ -318,13 +192,13 @@ func (e GKE) Update() (string, error) {
 // Destroy runs terraform destroy in the project
 func (e GKE) Destroy() (err error) {
 	log.Info("Destroying GKE project")
-	err = e.createVarFile()
+	varFile, err := e.createVarFile()
 	if err != nil {
 		return err
 	}
 	err = e.terraform.Destroy(
 		context.Background(),
-		tfexec.VarFile(fmt.Sprintf("%v/gke.tfvars", e.terraform.WorkingDir())),
+		tfexec.VarFile(varFile),
 	)
 	if err != nil {
 		log.Fatalf("Something went wrong while destroying GKE cluster project. %v", err)
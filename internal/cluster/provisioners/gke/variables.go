@@ -0,0 +1,102 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gke
+
+import (
+	cfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+// variables are the terraform variables of the GKE project
+type variables struct {
+	ClusterName                string            `json:"cluster_name"`
+	ClusterVersion             string            `json:"cluster_version"`
+	Network                    string            `json:"network"`
+	SubNetworks                []string          `json:"subnetworks"`
+	DMZCIDRRange               []string          `json:"dmz_cidr_range"`
+	SSHPublicKey               string            `json:"ssh_public_key"`
+	Tags                       map[string]string `json:"tags,omitempty"`
+	NodePools                  []nodePool        `json:"node_pools,omitempty"`
+	NetworkProjectID           string            `json:"gke_network_project_id"`
+	MasterIPv4CIDRBlock        string            `json:"gke_master_ipv4_cidr_block"`
+	AddAdditionalFirewallRules bool              `json:"gke_add_additional_firewall_rules"`
+	AddClusterFirewallRules    bool              `json:"gke_add_cluster_firewall_rules"`
+	DisableDefaultSNAT         bool              `json:"gke_disable_default_snat"`
+}
+
+type nodePool struct {
+	Name                    string            `json:"name"`
+	Version                 string            `json:"version"`
+	MinSize                 int               `json:"min_size"`
+	MaxSize                 int               `json:"max_size"`
+	InstanceType            string            `json:"instance_type"`
+	OS                      string            `json:"os,omitempty"`
+	MaxPods                 int               `json:"max_pods,omitempty"`
+	VolumeSize              int               `json:"volume_size"`
+	SpotInstance            bool              `json:"spot_instance"`
+	SubNetworks             []string          `json:"subnetworks"`
+	Labels                  map[string]string `json:"labels"`
+	Taints                  []string          `json:"taints"`
+	Tags                    map[string]string `json:"tags"`
+	AdditionalFirewallRules []firewallRule    `json:"additional_firewall_rules"`
+}
+
+type firewallRule struct {
+	Name      string            `json:"name"`
+	Direction string            `json:"direction"`
+	CIDRBlock string            `json:"cidr_block"`
+	Protocol  string            `json:"protocol"`
+	Ports     string            `json:"ports"`
+	Tags      map[string]string `json:"tags"`
+}
+
+func newVariables(config *configuration.Configuration) variables {
+	spec := config.Spec.(cfg.GKE)
+	v := variables{
+		ClusterName:                config.Metadata.Name,
+		ClusterVersion:             spec.Version,
+		Network:                    spec.Network,
+		SubNetworks:                terraform.Strings(spec.SubNetworks),
+		DMZCIDRRange:               terraform.Strings(spec.DMZCIDRRange.Values),
+		SSHPublicKey:               spec.SSHPublicKey,
+		Tags:                       configuration.ResourceTags(config),
+		NetworkProjectID:           spec.NetworkProjectID,
+		MasterIPv4CIDRBlock:        spec.ControlPlaneCIDR,
+		AddAdditionalFirewallRules: spec.AdditionalFirewallRules,
+		AddClusterFirewallRules:    spec.AdditionalClusterFirewallRules,
+		DisableDefaultSNAT:         spec.DisableDefaultSNAT,
+	}
+	for _, np := range spec.NodePools {
+		pool := nodePool{
+			Name:                    np.Name,
+			Version:                 np.Version,
+			MinSize:                 np.MinSize,
+			MaxSize:                 np.MaxSize,
+			InstanceType:            np.InstanceType,
+			OS:                      np.OS,
+			MaxPods:                 np.MaxPods,
+			VolumeSize:              np.VolumeSize,
+			SpotInstance:            np.SpotInstance,
+			SubNetworks:             terraform.Strings(np.SubNetworks),
+			Labels:                  terraform.StringMap(np.Labels),
+			Taints:                  terraform.Strings(np.Taints),
+			Tags:                    terraform.StringMap(np.Tags),
+			AdditionalFirewallRules: []firewallRule{},
+		}
+		for _, rule := range np.AdditionalFirewallRules {
+			pool.AdditionalFirewallRules = append(pool.AdditionalFirewallRules, firewallRule{
+				Name:      rule.Name,
+				Direction: rule.Direction,
+				CIDRBlock: rule.CIDRBlock,
+				Protocol:  rule.Protocol,
+				Ports:     rule.Ports,
+				Tags:      terraform.StringMap(rule.Tags),
+			})
+		}
+		v.NodePools = append(v.NodePools, pool)
+	}
+	return v
+}
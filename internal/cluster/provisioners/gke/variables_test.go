@@ -0,0 +1,92 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gke
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestVarFile(t *testing.T) {
+	config := &configuration.Configuration{
+		Kind:        "Cluster",
+		Provisioner: "gke",
+		Metadata:    configuration.Metadata{Name: "demo", Labels: map[string]interface{}{"team": "platform", "critical": true}},
+		Spec: cfg.GKE{
+			Version:                 "1.21",
+			Network:                 "network-1",
+			NetworkProjectID:        "shared-vpc",
+			ControlPlaneCIDR:        "10.0.0.0/28",
+			AdditionalFirewallRules: true,
+			SubNetworks:             []string{"subnet-1", "subnet-2", "subnet-3"},
+			DMZCIDRRange:            cfg.DMZCIDRRange{Values: []string{"0.0.0.0/0"}},
+			SSHPublicKey:            "ssh-rsa AAAA demo",
+			NodePools: []cfg.GKENodePool{
+				{
+					Name:         "infra",
+					Version:      "1.21",
+					MinSize:      1,
+					MaxSize:      3,
+					InstanceType: "n1-standard-2",
+					VolumeSize:   50,
+					Labels:       map[string]string{"description": `the "infra" nodes`},
+					Tags:         map[string]string{"path": `C:\nodes`},
+					AdditionalFirewallRules: []cfg.GKENodePoolFwRule{
+						{Name: "debug", Direction: "ingress", CIDRBlock: "10.0.0.0/8", Protocol: "TCP", Ports: "22-22", Tags: map[string]string{"team": "platform"}},
+					},
+				},
+				{
+					Name:         "app",
+					MaxSize:      10,
+					InstanceType: "n1-standard-4",
+					MaxPods:      64,
+					SpotInstance: true,
+					VolumeSize:   100,
+				},
+			},
+		},
+	}
+	testVarFile(t, "gke", newVariables(config))
+}
+
+// testVarFile writes the variables of a provisioner, comparing them with the golden file of the testdata
+func testVarFile(t *testing.T, name string, variables interface{}) {
+	dir, err := ioutil.TempDir("", "furyctl-tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := terraform.WriteVarFile(dir, name, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", name+".tfvars.json")
+	if *update {
+		if err = ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s.tfvars.json =\n%s\nwant\n%s", name, got, want)
+	}
+}
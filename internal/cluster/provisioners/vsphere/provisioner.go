@@ -5,7 +5,6 @@
 package vsphere
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -21,8 +20,8 @@ import (
 	getter "github.com/hashicorp/go-getter"
 	"github.com/hashicorp/terraform-exec/tfexec"
 	"github.com/relex/aini"
-	cfg "github.com/sighupio/furyctl/internal/cluster/configuration"
 	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -120,150 +119,32 @@ const (
 	projectPath = "../../../../data/provisioners/cluster/vsphere"
 )
 
-func (e VSphere) createVarFile() (err error) {
-	var buffer bytes.Buffer
-	spec := e.config.Spec.(cfg.VSphere)
-	buffer.WriteString(fmt.Sprintf("name = \"%v\"\n", e.config.Metadata.Name))
-	buffer.WriteString(fmt.Sprintf("kube_version = \"%v\"\n", spec.Version))
-	buffer.WriteString(fmt.Sprintf("kube_control_plane_endpoint = \"%v\"\n", spec.ControlPlaneEndpoint))
-	if spec.ETCDConfig.Version != "" {
-		buffer.WriteString(fmt.Sprintf("etcd_version = \"%v\"\n", spec.ETCDConfig.Version))
-	}
-	if spec.OIDCConfig.IssuerURL != "" {
-		buffer.WriteString(fmt.Sprintf("oidc_issuer_url = \"%v\"\n", spec.OIDCConfig.IssuerURL))
-	}
-	if spec.OIDCConfig.ClientID != "" {
-		buffer.WriteString(fmt.Sprintf("oidc_client_id = \"%v\"\n", spec.OIDCConfig.ClientID))
-	}
-	if spec.OIDCConfig.CAFile != "" {
-		buffer.WriteString(fmt.Sprintf("oidc_ca_file = \"%v\"\n", spec.OIDCConfig.CAFile))
-	}
+// tagsOverrideFile sets the custom attributes of the virtual machines, it is part of the project only with labels
+const tagsOverrideFile = "tags_override.tf"
 
-	if spec.CRIConfig.Version != "" {
-		buffer.WriteString(fmt.Sprintf("cri_version = \"%v\"\n", spec.CRIConfig.Version))
-	}
-	if spec.CRIConfig.Proxy != "" {
-		buffer.WriteString(fmt.Sprintf("cri_proxy = \"%v\"\n", spec.CRIConfig.Proxy))
-	}
-	if len(spec.CRIConfig.DNS) > 0 {
-		buffer.WriteString(fmt.Sprintf("cri_dns = [\"%v\"]\n", strings.Join(spec.CRIConfig.DNS, "\",\"")))
-	}
-	if len(spec.CRIConfig.Mirrors) > 0 {
-		buffer.WriteString(fmt.Sprintf("cri_mirrors = [\"%v\"]\n", strings.Join(spec.CRIConfig.Mirrors, "\",\"")))
+// createVarFile writes the variables of the configuration, returning the path of the var file.
+// It adds the tags override file when there are tags to set, removing it otherwise
+func (e VSphere) createVarFile() (string, error) {
+	v := newVariables(e.config)
+	if err := writeTagsOverride(e.box, e.terraform.WorkingDir(), len(v.Tags) > 0); err != nil {
+		return "", err
 	}
+	return terraform.WriteVarFile(e.terraform.WorkingDir(), "vsphere", v)
+}
 
-	buffer.WriteString(fmt.Sprintf("env = \"%v\"\n", spec.EnvironmentName))
-	buffer.WriteString(fmt.Sprintf("datacenter = \"%v\"\n", spec.Config.DatacenterName))
-	if len(spec.Config.EsxiHost) > 0 {
-		buffer.WriteString(fmt.Sprintf("esxihosts = [\"%v\"]\n", strings.Join(spec.Config.EsxiHost, "\",\"")))
-	} else {
-		buffer.WriteString("esxihosts = []\n")
-	}
-	buffer.WriteString(fmt.Sprintf("datastore = \"%v\"\n", spec.Config.Datastore))
-	buffer.WriteString(fmt.Sprintf("network = \"%v\"\n", spec.NetworkConfig.Name))
-	buffer.WriteString(fmt.Sprintf("net_cidr = \"%v\"\n", spec.ClusterCIDR))
-	buffer.WriteString(fmt.Sprintf("net_gateway = \"%v\"\n", spec.NetworkConfig.Gateway))
-	buffer.WriteString(fmt.Sprintf("net_nameservers = [\"%v\"]\n", strings.Join(spec.NetworkConfig.Nameservers, "\",\"")))
-	buffer.WriteString(fmt.Sprintf("net_domain = \"%v\"\n", spec.NetworkConfig.Domain))
-	buffer.WriteString(fmt.Sprintf("ip_offset = %v\n", spec.NetworkConfig.IPOffset))
-	buffer.WriteString(fmt.Sprintf("enable_boundary_targets = %v\n", spec.Boundary))
-	if len(spec.SSHPublicKey) > 0 {
-		buffer.WriteString(fmt.Sprintf("ssh_public_keys = [\"%v\"]\n", strings.Join(spec.SSHPublicKey, "\",\"")))
-	} else {
-		buffer.WriteString("ssh_public_keys = []\n")
-	}
-	buffer.WriteString(fmt.Sprintf("kube_lb_count = %v\n", spec.LoadBalancerNode.Count))
-	buffer.WriteString(fmt.Sprintf("kube_lb_template = \"%v\"\n", spec.LoadBalancerNode.Template))
-	buffer.WriteString(fmt.Sprintf("kube_lb_custom_script_path = \"%v\"\n", spec.LoadBalancerNode.CustomScriptPath))
-	buffer.WriteString(fmt.Sprintf("kube_master_count = %v\n", spec.MasterNode.Count))
-	buffer.WriteString(fmt.Sprintf("kube_master_cpu = %v\n", spec.MasterNode.CPU))
-	buffer.WriteString(fmt.Sprintf("kube_master_mem = %v\n", spec.MasterNode.MemSize))
-	buffer.WriteString(fmt.Sprintf("kube_master_disk_size = %v\n", spec.MasterNode.DiskSize))
-	buffer.WriteString(fmt.Sprintf("kube_master_template = \"%v\"\n", spec.MasterNode.Template))
-	// TODO: restore
-	if len(spec.MasterNode.Labels) > 0 {
-		var labels []byte
-		labels, err = json.Marshal(spec.MasterNode.Labels)
-		if err != nil {
+func writeTagsOverride(box *packr.Box, workingDir string, tags bool) error {
+	path := filepath.Join(workingDir, tagsOverrideFile)
+	if !tags {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
 			return err
 		}
-		buffer.WriteString(fmt.Sprintf("kube_master_labels = %v\n", string(labels)))
-	} else {
-		buffer.WriteString("kube_master_labels = {}\n")
+		return nil
 	}
-	if len(spec.MasterNode.Taints) > 0 {
-		buffer.WriteString(fmt.Sprintf("kube_master_taints = [\"%v\"]\n", strings.Join(spec.MasterNode.Taints, "\",\"")))
-	} else {
-		buffer.WriteString("kube_master_taints = []\n")
-	}
-	buffer.WriteString(fmt.Sprintf("kube_master_custom_script_path = \"%v\"\n", spec.MasterNode.CustomScriptPath))
-
-	buffer.WriteString(fmt.Sprintf("kube_pod_cidr = \"%v\"\n", spec.ClusterPODCIDR))
-	buffer.WriteString(fmt.Sprintf("kube_svc_cidr = \"%v\"\n", spec.ClusterSVCCIDR))
-
-	buffer.WriteString(fmt.Sprintf("kube_infra_count = %v\n", spec.InfraNode.Count))
-	buffer.WriteString(fmt.Sprintf("kube_infra_cpu = %v\n", spec.InfraNode.CPU))
-	buffer.WriteString(fmt.Sprintf("kube_infra_mem = %v\n", spec.InfraNode.MemSize))
-	buffer.WriteString(fmt.Sprintf("kube_infra_disk_size = %v\n", spec.InfraNode.DiskSize))
-	buffer.WriteString(fmt.Sprintf("kube_infra_template = \"%v\"\n", spec.InfraNode.Template))
-	if len(spec.InfraNode.Labels) > 0 {
-		var labels []byte
-		labels, err = json.Marshal(spec.InfraNode.Labels)
-		if err != nil {
-			return err
-		}
-		buffer.WriteString(fmt.Sprintf("kube_infra_labels = %v\n", string(labels)))
-	} else {
-		buffer.WriteString("kube_infra_labels = {}\n")
-	}
-	if len(spec.InfraNode.Taints) > 0 {
-		buffer.WriteString(fmt.Sprintf("kube_infra_taints = [\"%v\"]\n", strings.Join(spec.InfraNode.Taints, "\",\"")))
-	} else {
-		buffer.WriteString("kube_infra_taints = []\n")
-	}
-	buffer.WriteString(fmt.Sprintf("kube_infra_custom_script_path = \"%v\"\n", spec.InfraNode.CustomScriptPath))
-
-	if len(spec.NodePools) > 0 {
-		buffer.WriteString("node_pools = [\n")
-		for _, np := range spec.NodePools {
-			buffer.WriteString("{\n")
-			buffer.WriteString(fmt.Sprintf("role = \"%v\"\n", np.Role))
-			buffer.WriteString(fmt.Sprintf("template = \"%v\"\n", np.Template))
-			buffer.WriteString(fmt.Sprintf("count = %v\n", np.Count))
-			buffer.WriteString(fmt.Sprintf("memory = %v\n", np.MemSize))
-			buffer.WriteString(fmt.Sprintf("cpu = %v\n", np.CPU))
-			buffer.WriteString(fmt.Sprintf("disk_size = %v\n", np.DiskSize))
-			if len(np.Labels) > 0 {
-				var labels []byte
-				labels, err = json.Marshal(np.Labels)
-				if err != nil {
-					return err
-				}
-				buffer.WriteString(fmt.Sprintf("labels = %v\n", string(labels)))
-			} else {
-				buffer.WriteString("labels = {}\n")
-			}
-			if len(np.Taints) > 0 {
-				buffer.WriteString(fmt.Sprintf("taints = [\"%v\"]\n", strings.Join(np.Taints, "\",\"")))
-			} else {
-				buffer.WriteString("taints = []\n")
-			}
-			// TODO: restore
-			buffer.WriteString(fmt.Sprintf("custom_script_path = \"%v\"\n", ""))
-			buffer.WriteString("},\n")
-		}
-		buffer.WriteString("]\n")
-	}
-
-	err = ioutil.WriteFile(fmt.Sprintf("%v/vsphere.tfvars", e.terraform.WorkingDir()), buffer.Bytes(), 0600)
-	if err != nil {
-		return err
-	}
-	err = e.terraform.FormatWrite(context.Background(), tfexec.Dir(fmt.Sprintf("%v/vsphere.tfvars", e.terraform.WorkingDir())))
+	content, err := box.Find(tagsOverrideFile)
 	if err != nil {
 		return err
 	}
-	return nil
+	return ioutil.WriteFile(path, content, 0644)
 }
 
 // New instantiates a new vSphere provisioner
@@ -355,12 +236,12 @@ func downloadAnsibleRoles(workingDirectory string) error {
 func (e VSphere) Plan() (err error) {
 	log.Info("[DRYRUN] Updating VSphere Cluster project")
 	// TODO: give the name of the file
-	err = e.createVarFile()
+	varFile, err := e.createVarFile()
 	if err != nil {
 		return err
 	}
 	var changes bool
-	changes, err = e.terraform.Plan(context.Background(), tfexec.VarFile(fmt.Sprintf("%v/vsphere.tfvars", e.terraform.WorkingDir())))
+	changes, err = e.terraform.Plan(context.Background(), tfexec.VarFile(varFile))
 	if err != nil {
 		log.Fatalf("[DRYRUN] Something went wrong while updating vsphere. %v", err)
 		return err
@@ -378,11 +259,11 @@ func (e VSphere) Plan() (err error) {
 // Update runs terraform apply in the project
 func (e VSphere) Update() (string, error) {
 	log.Info("Updating VSphere project")
-	err := e.createVarFile()
+	varFile, err := e.createVarFile()
 	if err != nil {
 		return "", err
 	}
-	err = e.terraform.Apply(context.Background(), tfexec.VarFile(fmt.Sprintf("%v/vsphere.tfvars", e.terraform.WorkingDir())))
+	err = e.terraform.Apply(context.Background(), tfexec.VarFile(varFile))
 	if err != nil {
 		log.Fatalf("Something went wrong while updating vsphere. %v", err)
 		return "", err
@@ -427,11 +308,11 @@ func (e VSphere) Update() (string, error) {
 // Destroy runs terraform destroy in the project
 func (e VSphere) Destroy() (err error) {
 	log.Info("Destroying VSphere project")
-	err = e.createVarFile()
+	varFile, err := e.createVarFile()
 	if err != nil {
 		return err
 	}
-	err = e.terraform.Destroy(context.Background(), tfexec.VarFile(fmt.Sprintf("%v/vsphere.tfvars", e.terraform.WorkingDir())))
+	err = e.terraform.Destroy(context.Background(), tfexec.VarFile(varFile))
 	if err != nil {
 		log.Fatalf("Something went wrong while destroying VSphere cluster project. %v", err)
 		return err
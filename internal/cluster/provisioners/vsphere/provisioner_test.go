@@ -0,0 +1,44 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vsphere
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sighupio/furyctl/internal/configuration"
+)
+
+func TestWriteTagsOverride(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-vsphere")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	box := New(&configuration.Configuration{}).Box()
+	path := filepath.Join(dir, tagsOverrideFile)
+
+	if err = writeTagsOverride(box, dir, true); err != nil {
+		t.Fatalf("writeTagsOverride() error = %v", err)
+	}
+	content, err := ioutil.ReadFile(path)
+	if err != nil || !strings.Contains(string(content), "custom_attributes = local.custom_attributes") {
+		t.Errorf("writeTagsOverride() with tags = %s, %v, want the module override", content, err)
+	}
+
+	// a project whose labels are removed must not keep the override
+	if err = writeTagsOverride(box, dir, false); err != nil {
+		t.Fatalf("writeTagsOverride() error = %v", err)
+	}
+	if _, err = os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("writeTagsOverride() without tags kept %s: %v", tagsOverrideFile, err)
+	}
+	if err = writeTagsOverride(box, dir, false); err != nil {
+		t.Errorf("writeTagsOverride() without tags nor override error = %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vsphere
+
+import (
+	cfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+// variables are the terraform variables of the vSphere project
+type variables struct {
+	Name                     string            `json:"name"`
+	KubeVersion              string            `json:"kube_version"`
+	KubeControlPlaneEndpoint string            `json:"kube_control_plane_endpoint"`
+	ETCDVersion              string            `json:"etcd_version,omitempty"`
+	OIDCIssuerURL            string            `json:"oidc_issuer_url,omitempty"`
+	OIDCClientID             string            `json:"oidc_client_id,omitempty"`
+	OIDCCAFile               string            `json:"oidc_ca_file,omitempty"`
+	CRIVersion               string            `json:"cri_version,omitempty"`
+	CRIProxy                 string            `json:"cri_proxy,omitempty"`
+	CRIDNS                   []string          `json:"cri_dns,omitempty"`
+	CRIMirrors               []string          `json:"cri_mirrors,omitempty"`
+	Env                      string            `json:"env"`
+	Tags                     map[string]string `json:"tags,omitempty"`
+	Datacenter               string            `json:"datacenter"`
+	ESXiHosts                []string          `json:"esxihosts"`
+	Datastore                string            `json:"datastore"`
+	Network                  string            `json:"network"`
+	NetCIDR                  string            `json:"net_cidr"`
+	NetGateway               string            `json:"net_gateway"`
+	NetNameservers           []string          `json:"net_nameservers"`
+	NetDomain                string            `json:"net_domain"`
+	IPOffset                 int               `json:"ip_offset"`
+	EnableBoundaryTargets    bool              `json:"enable_boundary_targets"`
+	SSHPublicKeys            []string          `json:"ssh_public_keys"`
+
+	KubeLBCount            int    `json:"kube_lb_count"`
+	KubeLBTemplate         string `json:"kube_lb_template"`
+	KubeLBCustomScriptPath string `json:"kube_lb_custom_script_path"`
+
+	KubeMasterCount            int               `json:"kube_master_count"`
+	KubeMasterCPU              int               `json:"kube_master_cpu"`
+	KubeMasterMem              int               `json:"kube_master_mem"`
+	KubeMasterDiskSize         int               `json:"kube_master_disk_size"`
+	KubeMasterTemplate         string            `json:"kube_master_template"`
+	KubeMasterLabels           map[string]string `json:"kube_master_labels"`
+	KubeMasterTaints           []string          `json:"kube_master_taints"`
+	KubeMasterCustomScriptPath string            `json:"kube_master_custom_script_path"`
+
+	KubePodCIDR string `json:"kube_pod_cidr"`
+	KubeSvcCIDR string `json:"kube_svc_cidr"`
+
+	KubeInfraCount            int               `json:"kube_infra_count"`
+	KubeInfraCPU              int               `json:"kube_infra_cpu"`
+	KubeInfraMem              int               `json:"kube_infra_mem"`
+	KubeInfraDiskSize         int               `json:"kube_infra_disk_size"`
+	KubeInfraTemplate         string            `json:"kube_infra_template"`
+	KubeInfraLabels           map[string]string `json:"kube_infra_labels"`
+	KubeInfraTaints           []string          `json:"kube_infra_taints"`
+	KubeInfraCustomScriptPath string            `json:"kube_infra_custom_script_path"`
+
+	NodePools []nodePool `json:"node_pools,omitempty"`
+}
+
+type nodePool struct {
+	Role             string            `json:"role"`
+	Template         string            `json:"template"`
+	Count            int               `json:"count"`
+	Memory           int               `json:"memory"`
+	CPU              int               `json:"cpu"`
+	DiskSize         int               `json:"disk_size"`
+	Labels           map[string]string `json:"labels"`
+	Taints           []string          `json:"taints"`
+	CustomScriptPath string            `json:"custom_script_path"`
+}
+
+func newVariables(config *configuration.Configuration) variables {
+	spec := config.Spec.(cfg.VSphere)
+	v := variables{
+		Name:                     config.Metadata.Name,
+		KubeVersion:              spec.Version,
+		KubeControlPlaneEndpoint: spec.ControlPlaneEndpoint,
+		ETCDVersion:              spec.ETCDConfig.Version,
+		OIDCIssuerURL:            spec.OIDCConfig.IssuerURL,
+		OIDCClientID:             spec.OIDCConfig.ClientID,
+		OIDCCAFile:               spec.OIDCConfig.CAFile,
+		CRIVersion:               spec.CRIConfig.Version,
+		CRIProxy:                 spec.CRIConfig.Proxy,
+		CRIDNS:                   spec.CRIConfig.DNS,
+		CRIMirrors:               spec.CRIConfig.Mirrors,
+		Env:                      spec.EnvironmentName,
+		Tags:                     configuration.ResourceTags(config),
+		Datacenter:               spec.Config.DatacenterName,
+		ESXiHosts:                terraform.Strings(spec.Config.EsxiHost),
+		Datastore:                spec.Config.Datastore,
+		Network:                  spec.NetworkConfig.Name,
+		NetCIDR:                  spec.ClusterCIDR,
+		NetGateway:               spec.NetworkConfig.Gateway,
+		NetNameservers:           terraform.Strings(spec.NetworkConfig.Nameservers),
+		NetDomain:                spec.NetworkConfig.Domain,
+		IPOffset:                 spec.NetworkConfig.IPOffset,
+		EnableBoundaryTargets:    spec.Boundary,
+		SSHPublicKeys:            terraform.Strings(spec.SSHPublicKey),
+
+		KubeLBCount:            spec.LoadBalancerNode.Count,
+		KubeLBTemplate:         spec.LoadBalancerNode.Template,
+		KubeLBCustomScriptPath: spec.LoadBalancerNode.CustomScriptPath,
+
+		KubeMasterCount:            spec.MasterNode.Count,
+		KubeMasterCPU:              spec.MasterNode.CPU,
+		KubeMasterMem:              spec.MasterNode.MemSize,
+		KubeMasterDiskSize:         spec.MasterNode.DiskSize,
+		KubeMasterTemplate:         spec.MasterNode.Template,
+		KubeMasterLabels:           terraform.StringMap(spec.MasterNode.Labels),
+		KubeMasterTaints:           terraform.Strings(spec.MasterNode.Taints),
+		KubeMasterCustomScriptPath: spec.MasterNode.CustomScriptPath,
+
+		KubePodCIDR: spec.ClusterPODCIDR,
+		KubeSvcCIDR: spec.ClusterSVCCIDR,
+
+		KubeInfraCount:            spec.InfraNode.Count,
+		KubeInfraCPU:              spec.InfraNode.CPU,
+		KubeInfraMem:              spec.InfraNode.MemSize,
+		KubeInfraDiskSize:         spec.InfraNode.DiskSize,
+		KubeInfraTemplate:         spec.InfraNode.Template,
+		KubeInfraLabels:           terraform.StringMap(spec.InfraNode.Labels),
+		KubeInfraTaints:           terraform.Strings(spec.InfraNode.Taints),
+		KubeInfraCustomScriptPath: spec.InfraNode.CustomScriptPath,
+	}
+	for _, np := range spec.NodePools {
+		v.NodePools = append(v.NodePools, nodePool{
+			Role:     np.Role,
+			Template: np.Template,
+			Count:    np.Count,
+			Memory:   np.MemSize,
+			CPU:      np.CPU,
+			DiskSize: np.DiskSize,
+			Labels:   terraform.StringMap(np.Labels),
+			Taints:   terraform.Strings(np.Taints),
+			// TODO: restore
+			CustomScriptPath: "",
+		})
+	}
+	return v
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vsphere
+
+import (
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/pkg/terraform"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+func TestVarFile(t *testing.T) {
+	config := &configuration.Configuration{
+		Kind:        "Cluster",
+		Provisioner: "vsphere",
+		Metadata:    configuration.Metadata{Name: "demo", Labels: map[string]interface{}{"team": "platform", "replicas": 3}},
+		Spec: cfg.VSphere{
+			Version:              "1.20.5",
+			ControlPlaneEndpoint: "demo.example.com:6443",
+			ETCDConfig:           cfg.VSphereETCDConfig{Version: "v3.4.15"},
+			OIDCConfig:           cfg.VSphereOIDCConfig{IssuerURL: "https://dex.example.com", ClientID: "kubernetes"},
+			CRIConfig:            cfg.VSphereCRIConfig{Version: "19.03", Mirrors: []string{"https://mirror.example.com"}},
+			EnvironmentName:      "production",
+			Config:               cfg.VSphereConfig{DatacenterName: "dc1", Datastore: "datastore1", EsxiHost: []string{"esxi-1"}},
+			NetworkConfig: cfg.VSphereNetworkConfig{
+				Name:        "VM Network",
+				Gateway:     "10.2.0.1",
+				Nameservers: []string{"10.2.0.1"},
+				Domain:      "localdomain",
+			},
+			Boundary:         true,
+			LoadBalancerNode: cfg.VSphereKubeLoadBalancer{Count: 1, Template: "templates/centos"},
+			MasterNode: cfg.VSphereKubeNode{
+				Count:            3,
+				CPU:              2,
+				MemSize:          8192,
+				DiskSize:         100,
+				Template:         "templates/centos",
+				CustomScriptPath: `C:\scripts\master.sh`,
+			},
+			InfraNode: cfg.VSphereKubeNode{
+				Count:    3,
+				CPU:      4,
+				MemSize:  16384,
+				DiskSize: 100,
+				Template: "templates/centos",
+				Labels:   map[string]string{"node-kind.sighup.io/infra": ""},
+				Taints:   []string{"node.kubernetes.io/role=infra:NoSchedule"},
+			},
+			NodePools: []cfg.VSphereKubeNode{
+				{Role: "app", Count: 2, CPU: 4, MemSize: 8192, DiskSize: 100, Template: "templates/centos", Labels: map[string]string{"description": `the "app" nodes`}},
+			},
+			ClusterPODCIDR: "172.21.0.0/16",
+			ClusterSVCCIDR: "172.23.0.0/16",
+			ClusterCIDR:    "10.2.0.0/16",
+			SSHPublicKey:   []string{"ssh-rsa AAAA demo"},
+		},
+	}
+	testVarFile(t, "vsphere", newVariables(config))
+}
+
+// testVarFile writes the variables of a provisioner, comparing them with the golden file of the testdata
+func testVarFile(t *testing.T, name string, variables interface{}) {
+	dir, err := ioutil.TempDir("", "furyctl-tfvars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path, err := terraform.WriteVarFile(dir, name, variables)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	golden := filepath.Join("testdata", name+".tfvars.json")
+	if *update {
+		if err = ioutil.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s.tfvars.json =\n%s\nwant\n%s", name, got, want)
+	}
+}
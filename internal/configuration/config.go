@@ -7,7 +7,10 @@ package configuration
 import (
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
 
 	bootstrapcfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
 	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
@@ -30,11 +33,13 @@ type StateConfiguration struct {
 
 // Configuration represents the base of the configuration file
 type Configuration struct {
+	APIVersion  string            `yaml:"apiVersion"`
 	Kind        string            `yaml:"kind"`
 	Metadata    Metadata          `yaml:"metadata"`
 	Spec        interface{}       `yaml:"spec"`
 	Executor    TerraformExecutor `yaml:"executor"`
 	Provisioner string            `yaml:"provisioner"`
+	Hooks       Hooks             `yaml:"hooks"`
 }
 
 // Metadata represents a set of metadata information to be used while performing operations
@@ -43,40 +48,104 @@ type Metadata struct {
 	Labels map[string]interface{} `yaml:"labels"`
 }
 
-// Parse parses a yaml configuration file (path) returning the parsed configuration file as a Configuration struct
-func Parse(path string) (*Configuration, error) {
-	content, err := ioutil.ReadFile(path)
+// Parse parses a yaml configuration file, read from a reader named source in the messages, returning the parsed
+// configuration file as a Configuration struct. The overlays are the locations of the files merged in order into
+// the configuration file: maps are merged key by key, lists of objects with a name are merged by name and any other
+// value is replaced
+func Parse(r io.Reader, source string, overlays ...string) (*Configuration, error) {
+	return ParseWithOptions(r, source, Options{Overlays: overlays})
+}
+
+// ParseWithOptions parses a yaml configuration file as Parse does, configured by the options
+func ParseWithOptions(r io.Reader, source string, opts Options) (*Configuration, error) {
+	config, err := parse(r, source, opts)
 	if err != nil {
 		log.Errorf("error parsing configuration file: %v", err)
 		return nil, err
 	}
+	return config, nil
+}
+
+// Check parses a yaml configuration file as ParseWithOptions does, returning the errors and the warnings found
+// instead of logging them. The errors without a position, as the yaml syntax ones, are located at their line or at the top of their file
+func Check(r io.Reader, source string, opts Options) (errs ValidationErrors, warnings ValidationErrors) {
+	opts.Warn = func(w ValidationError) {
+		warnings = append(warnings, w)
+	}
+	_, err := parse(r, source, opts)
+	if err == nil {
+		return nil, warnings
+	}
+	if validationErrs, ok := err.(ValidationErrors); ok {
+		return validationErrs, warnings
+	}
+	e := ValidationError{File: source, Line: 1, Column: 1, Message: err.Error()}
+	for _, file := range append([]string{source}, opts.Overlays...) {
+		if file == Stdin {
+			file = stdinName
+		}
+		if strings.HasPrefix(e.Message, file+": ") {
+			e.File, e.Message = file, strings.TrimPrefix(e.Message, file+": ")
+		}
+	}
+	if match := linePattern.FindStringSubmatch(e.Message); match != nil {
+		e.Line, _ = strconv.Atoi(match[1])
+	}
+	return ValidationErrors{e}, warnings
+}
+
+// linePattern matches the line of the yaml syntax errors
+var linePattern = regexp.MustCompile(`^yaml: line (\d+):`)
+
+func parse(r io.Reader, source string, opts Options) (*Configuration, error) {
+	doc, err := load(r, source, opts, true)
+	if err != nil {
+		return nil, err
+	}
+	applyDefaults(doc.root, false)
+	content, err := encodeDocument(doc.node)
+	if err != nil {
+		return nil, err
+	}
+	// the schema reports the unknown fields with their position, the strict decoding catches the ones it cannot check
+	unmarshal := yaml.UnmarshalStrict
+	if opts.AllowUnknownFields {
+		unmarshal = yaml.Unmarshal
+	}
 	baseConfig := &Configuration{}
-	err = yaml.Unmarshal(content, &baseConfig)
+	err = unmarshal(content, &baseConfig)
 	if err != nil {
-		log.Errorf("error parsing configuration file: %v", err)
 		return nil, err
 	}
 
 	switch {
 	case baseConfig.Kind == "Cluster":
-		err = clusterParser(baseConfig)
-		if err != nil {
-			return nil, err
-		}
-		return baseConfig, nil
+		err = clusterParser(baseConfig, unmarshal)
 	case baseConfig.Kind == "Bootstrap":
-		err = bootstrapParser(baseConfig)
-		if err != nil {
-			return nil, err
-		}
-		return baseConfig, nil
+		err = bootstrapParser(baseConfig, unmarshal)
 	default:
-		log.Errorf("Error parsing the configuration file. Parser not found for %v kind", baseConfig.Kind)
 		return nil, fmt.Errorf("parser not found for %v kind", baseConfig.Kind)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	errs := validateNetwork(baseConfig.Spec)
+	tagErrs, warnings := validateTags(baseConfig)
+	errs = append(errs, tagErrs...)
+	errs = append(errs, validateHooks(baseConfig.Hooks)...)
+	doc.locate(warnings)
+	for _, w := range warnings {
+		opts.warn(w)
+	}
+	if len(errs) > 0 {
+		doc.locate(errs)
+		return nil, errs
+	}
+	return baseConfig, nil
 }
 
-func clusterParser(config *Configuration) (err error) {
+func clusterParser(config *Configuration, unmarshal func([]byte, interface{}) error) (err error) {
 	provisioner := config.Provisioner
 	log.Debugf("provisioner: %v", provisioner)
 	specBytes, err := yaml.Marshal(config.Spec)
@@ -87,7 +156,7 @@ func clusterParser(config *Configuration) (err error) {
 	switch {
 	case provisioner == "eks":
 		eksSpec := clustercfg.EKS{}
-		err = yaml.Unmarshal(specBytes, &eksSpec)
+		err = unmarshal(specBytes, &eksSpec)
 		if err != nil {
 			log.Errorf("error parsing configuration file: %v", err)
 			return err
@@ -95,14 +164,8 @@ func clusterParser(config *Configuration) (err error) {
 		config.Spec = eksSpec
 		return nil
 	case provisioner == "gke":
-		gkeSpec := clustercfg.GKE{
-			NetworkProjectID:               "",
-			ControlPlaneCIDR:               "10.0.0.0/28",
-			AdditionalFirewallRules:        true,
-			AdditionalClusterFirewallRules: false,
-			DisableDefaultSNAT:             false,
-		}
-		err = yaml.Unmarshal(specBytes, &gkeSpec)
+		gkeSpec := clustercfg.GKE{}
+		err = unmarshal(specBytes, &gkeSpec)
 		if err != nil {
 			log.Errorf("error parsing configuration file: %v", err)
 			return err
@@ -110,13 +173,8 @@ func clusterParser(config *Configuration) (err error) {
 		config.Spec = gkeSpec
 		return nil
 	case provisioner == "vsphere":
-		vsphereSpec := clustercfg.VSphere{
-			NetworkConfig: clustercfg.VSphereNetworkConfig{
-				Domain:   "localdomain",
-				IPOffset: 0,
-			},
-		}
-		err = yaml.Unmarshal(specBytes, &vsphereSpec)
+		vsphereSpec := clustercfg.VSphere{}
+		err = unmarshal(specBytes, &vsphereSpec)
 		if err != nil {
 			log.Errorf("error parsing configuration file: %v", err)
 			return err
@@ -129,7 +187,7 @@ func clusterParser(config *Configuration) (err error) {
 	}
 }
 
-func bootstrapParser(config *Configuration) (err error) {
+func bootstrapParser(config *Configuration, unmarshal func([]byte, interface{}) error) (err error) {
 	provisioner := config.Provisioner
 	log.Debugf("provisioner: %v", provisioner)
 	specBytes, err := yaml.Marshal(config.Spec)
@@ -139,12 +197,8 @@ func bootstrapParser(config *Configuration) (err error) {
 	}
 	switch {
 	case provisioner == "aws":
-		awsSpec := bootstrapcfg.AWS{
-			VPN: bootstrapcfg.AWSVPN{
-				Instances: 1,
-			},
-		}
-		err = yaml.Unmarshal(specBytes, &awsSpec)
+		awsSpec := bootstrapcfg.AWS{}
+		err = unmarshal(specBytes, &awsSpec)
 		if err != nil {
 			log.Errorf("error parsing configuration file: %v", err)
 			return err
@@ -152,12 +206,8 @@ func bootstrapParser(config *Configuration) (err error) {
 		config.Spec = awsSpec
 		return nil
 	case provisioner == "gcp":
-		gcpSpec := bootstrapcfg.GCP{
-			VPN: bootstrapcfg.GCPVPN{
-				Instances: 1,
-			},
-		}
-		err = yaml.Unmarshal(specBytes, &gcpSpec)
+		gcpSpec := bootstrapcfg.GCP{}
+		err = unmarshal(specBytes, &gcpSpec)
 		if err != nil {
 			log.Errorf("error parsing configuration file: %v", err)
 			return err
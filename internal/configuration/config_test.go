@@ -5,6 +5,9 @@
 package configuration
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
@@ -17,11 +20,15 @@ var sampleAWSBootstrap Configuration
 
 func init() {
 
+	sampleAWSBootstrap.APIVersion = APIVersion
 	sampleAWSBootstrap.Kind = "Bootstrap"
 	sampleAWSBootstrap.Metadata = Metadata{
 		Name: "my-aws-poc",
 	}
 	sampleAWSBootstrap.Provisioner = "aws"
+	// the file has no executor, the state backend defaults to local
+	sampleAWSBootstrap.Executor.StateConfiguration.Backend = "local"
+	sampleAWSBootstrap.Hooks.Timeout = defaultHookTimeout
 	sampleAWSBootstrap.Spec = bootstrapcfg.AWS{
 		NetworkCIDR:         "10.0.0.0/16",
 		PublicSubnetsCIDRs:  []string{"10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"},
@@ -39,6 +46,7 @@ func init() {
 		},
 	}
 
+	sampleEKSConfig.APIVersion = APIVersion
 	sampleEKSConfig.Kind = "Cluster"
 	sampleEKSConfig.Metadata = Metadata{
 		Name: "demo",
@@ -52,6 +60,7 @@ func init() {
 		},
 	}
 	sampleEKSConfig.Provisioner = "eks"
+	sampleEKSConfig.Hooks.Timeout = defaultHookTimeout
 	sampleEKSConfig.Spec = clustercfg.EKS{
 		Version:      "1.18",
 		Network:      "vpc-1",
@@ -149,7 +158,7 @@ func TestParseClusterConfigurationFile(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := Parse(tt.args.path)
+			got, err := Parse(readFile(t, tt.args.path))
 			if (err != nil) != tt.wantErr {
 				t.Errorf("ParseClusterConfigurationFile() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -160,3 +169,42 @@ func TestParseClusterConfigurationFile(t *testing.T) {
 		})
 	}
 }
+
+func TestCheck(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"cluster.yml": `kind: Cluster
+metadata:
+  name: demo
+provisioner: vsphere
+spec:
+  networkConfig:
+    gateway: 10.1.0.1
+    nameservers:
+      - 1.1.1.1
+  clusterCIDR: 10.2.0.0/16
+`,
+		"broken.yml": "kind: Cluster\nmetadata: [\n",
+	})
+	defer os.RemoveAll(dir)
+
+	r, source := readFile(t, filepath.Join(dir, "cluster.yml"))
+	errs, warnings := Check(r, source, Options{})
+	var got []string
+	for _, e := range append(errs, warnings...) {
+		got = append(got, fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message))
+	}
+	want := []string{
+		"7:14: spec.networkConfig.gateway: 10.1.0.1 is not inside spec.clusterCIDR 10.2.0.0/16",
+		"9:9: spec.networkConfig.nameservers[0]: 1.1.1.1 is not inside spec.clusterCIDR 10.2.0.0/16",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Check() =\n%v\nwant\n%v", got, want)
+	}
+
+	path := filepath.Join(dir, "broken.yml")
+	r, _ = readFile(t, path)
+	errs, _ = Check(r, path, Options{})
+	if len(errs) != 1 || errs[0].File != path || errs[0].Line != 2 {
+		t.Errorf("Check() of a yaml syntax error = %v, want an error at line 2 of %s", errs, path)
+	}
+}
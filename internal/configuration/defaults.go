@@ -0,0 +1,111 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"reflect"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// defaultComment marks the values set by the defaults in the defaulted view
+const defaultComment = "default"
+
+// applyDefaults sets the documented default of every missing field of a configuration file, it is the only place
+// the defaults are applied. The objects missing from the file are added when they contain a default.
+// The defaulted values are marked with a comment when mark is set
+func applyDefaults(root *yamlv3.Node, mark bool) {
+	spec, ok := specs[scalarValue(root, "kind")][scalarValue(root, "provisioner")]
+	if !ok {
+		// the parser reports the unknown kinds and provisioners
+		return
+	}
+	d := defaulter{mark: mark, spec: reflect.TypeOf(spec)}
+	d.object(root, reflect.TypeOf(Configuration{}))
+}
+
+// defaulter walks the yaml nodes of a configuration file along its types
+type defaulter struct {
+	mark bool
+	// spec is the type of the spec of the configuration
+	spec reflect.Type
+}
+
+// object sets the defaults of a yaml object of a struct type, returning whether it set any
+func (d defaulter) object(node *yamlv3.Node, t reflect.Type) bool {
+	defaulted := false
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		fieldType := t.Field(i).Type
+		if t == reflect.TypeOf(Configuration{}) && name == "spec" {
+			fieldType = d.spec
+		}
+		key := keyIndex(node, name)
+		if key >= 0 && node.Content[key+1].Tag != "!!null" {
+			d.nested(node.Content[key+1], fieldType)
+			continue
+		}
+		value := d.missing(lookupField(t, name), fieldType)
+		if value == nil {
+			continue
+		}
+		if key < 0 {
+			node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: name}, nil)
+			key = len(node.Content) - 2
+		}
+		node.Content[key+1] = value
+		// the objects added for their nested defaults are marked by their fields
+		switch {
+		case !d.mark:
+		case value.Kind == yamlv3.ScalarNode:
+			value.LineComment = defaultComment
+		case value.Kind == yamlv3.SequenceNode:
+			node.Content[key].LineComment = defaultComment
+		}
+		defaulted = true
+	}
+	return defaulted
+}
+
+// nested sets the defaults of the objects of a field written in the file, and of the items of its lists
+func (d defaulter) nested(node *yamlv3.Node, t reflect.Type) {
+	if _, ok := customSchemas[t]; ok {
+		return
+	}
+	switch {
+	case t.Kind() == reflect.Ptr:
+		d.nested(node, t.Elem())
+	case t.Kind() == reflect.Struct && node.Kind == yamlv3.MappingNode:
+		d.object(node, t)
+	case t.Kind() == reflect.Slice && node.Kind == yamlv3.SequenceNode:
+		for _, item := range node.Content {
+			d.nested(item, t.Elem())
+		}
+	}
+}
+
+// missing returns the value of a field missing from the file: its default, or the object of its nested defaults.
+// It is nil when the field has neither
+func (d defaulter) missing(f field, t reflect.Type) *yamlv3.Node {
+	if f.defaultValue != nil {
+		node := &yamlv3.Node{}
+		if err := node.Encode(f.defaultValue); err != nil {
+			// the defaults are plain values, this cannot happen
+			return nil
+		}
+		return node
+	}
+	if _, ok := customSchemas[t]; ok || t.Kind() != reflect.Struct {
+		return nil
+	}
+	node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	if !d.object(node, t) {
+		return nil
+	}
+	return node
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// indexPattern matches the list indexes of a field path, as nodePools[0] or nodePools[]
+var indexPattern = regexp.MustCompile(`\[\d*\]`)
+
+// Explain describes a field of the configuration file of a kind and provisioner: its type, whether it is required,
+// its default, its accepted values, the terraform variable it sets and its child fields.
+// The path is dotted, as spec.nodePools.maxPods, an empty path describes the whole configuration file
+func Explain(kind string, provisioner string, path string) (string, error) {
+	for _, k := range Kinds() {
+		if strings.EqualFold(k, kind) {
+			kind = k
+		}
+	}
+	if _, err := Schema(kind, provisioner); err != nil {
+		return "", err
+	}
+
+	t := reflect.TypeOf(Configuration{})
+	f := field{description: fmt.Sprintf("furyctl %s configuration for the %s provisioner", kind, provisioner)}
+	walked := ""
+	for _, name := range strings.Split(indexPattern.ReplaceAllString(path, ""), ".") {
+		if name == "" {
+			continue
+		}
+		parent := structType(t)
+		if parent == nil {
+			return "", fmt.Errorf("%s has no fields", walked)
+		}
+		child, ok := structField(parent, name)
+		if !ok {
+			where := walked
+			if where == "" {
+				where = "<root>"
+			}
+			return "", fmt.Errorf("%s: %s", where, schemaOf(parent).unknownField(walked, name))
+		}
+		f = lookupField(parent, name)
+		t = child.Type
+		if parent == reflect.TypeOf(Configuration{}) && name == "spec" {
+			t = reflect.TypeOf(specs[kind][provisioner])
+		}
+		walked = joinPath(walked, name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "KIND:        %s\n", kind)
+	fmt.Fprintf(&b, "PROVISIONER: %s\n", provisioner)
+	if walked != "" {
+		fmt.Fprintf(&b, "FIELD:       %s <%s>\n", walked, typeName(schemaOf(t)))
+		required := "false"
+		if f.required {
+			required = "true"
+		}
+		fmt.Fprintf(&b, "REQUIRED:    %s\n", required)
+	}
+	if f.defaultValue != nil {
+		fmt.Fprintf(&b, "DEFAULT:     %s\n", formatValue(f.defaultValue))
+	}
+	if len(f.enum) > 0 {
+		fmt.Fprintf(&b, "VALUES:      %s\n", strings.Join(f.enum, ", "))
+	}
+	if variable, ok := variables[kind][provisioner][walked]; ok {
+		fmt.Fprintf(&b, "TERRAFORM:   %s\n", variable)
+	}
+	fmt.Fprintf(&b, "\nDESCRIPTION:\n    %s\n", f.description)
+
+	if st := structType(t); st != nil {
+		if _, ok := customSchemas[t]; !ok {
+			b.WriteString("\nFIELDS:\n")
+			for i := 0; i < st.NumField(); i++ {
+				name := yamlName(st.Field(i))
+				if name == "" {
+					continue
+				}
+				child := lookupField(st, name)
+				childType := st.Field(i).Type
+				if st == reflect.TypeOf(Configuration{}) && name == "spec" {
+					childType = reflect.TypeOf(specs[kind][provisioner])
+				}
+				required := ""
+				if child.required {
+					required = " -required-"
+				}
+				fmt.Fprintf(&b, "    %s <%s>%s\n        %s\n", name, typeName(schemaOf(childType)), required, child.description)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// structType returns the struct of a type, or of the items of a list, nil when the type has no fields
+func structType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	return t
+}
+
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// typeName describes a schema as Object, []string, map[string]string or string | []string
+func typeName(s *JSONSchema) string {
+	switch {
+	case len(s.OneOf) > 0:
+		names := make([]string, 0, len(s.OneOf))
+		for _, o := range s.OneOf {
+			names = append(names, typeName(o))
+		}
+		return strings.Join(names, " | ")
+	case s.Type == "array":
+		return "[]" + typeName(s.Items)
+	case s.Type == "object" && s.AdditionalProperties != nil:
+		return "map[string]" + typeName(s.AdditionalProperties)
+	case s.Type == "object":
+		return "Object"
+	case s.Type == "":
+		return "any"
+	default:
+		return s.Type
+	}
+}
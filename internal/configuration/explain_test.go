@@ -0,0 +1,73 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	got, err := Explain("cluster", "eks", "spec.nodePools[0].additionalFirewallRules.direction")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `KIND:        Cluster
+PROVISIONER: eks
+FIELD:       spec.nodePools.additionalFirewallRules.direction <string>
+REQUIRED:    true
+VALUES:      ingress, egress
+TERRAFORM:   node_pools[].additional_firewall_rules[].direction
+
+DESCRIPTION:
+    Direction of the traffic
+`
+	if got != want {
+		t.Errorf("Explain() =\n%s\nwant\n%s", got, want)
+	}
+
+	got, err = Explain("Bootstrap", "aws", "spec.vpn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"FIELD:       spec.vpn <Object>",
+		"FIELDS:",
+		"    port <integer>\n        Port of the VPN servers",
+		"    operatorCIDRs <[]string>",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("Explain() =\n%s\nwant it to contain %q", got, line)
+		}
+	}
+}
+
+func TestExplainErrors(t *testing.T) {
+	tests := []struct {
+		kind, provisioner, path, want string
+	}{
+		{"Cluster", "aks", "", "unknown Cluster provisioner aks. Choose one of: eks, gke, vsphere"},
+		{"Cluster", "eks", "spec.nodepool", "spec: unknown field nodepool, did you mean nodePools?"},
+		{"Cluster", "eks", "spec.version.minor", "spec.version has no fields"},
+	}
+	for _, tt := range tests {
+		if _, err := Explain(tt.kind, tt.provisioner, tt.path); err == nil || err.Error() != tt.want {
+			t.Errorf("Explain(%s, %s, %s) error = %v, want %s", tt.kind, tt.provisioner, tt.path, err, tt.want)
+		}
+	}
+}
+
+// TestVariablesExist checks every terraform variable is mapped from a field of the configuration
+func TestVariablesExist(t *testing.T) {
+	for kind, provisioners := range variables {
+		for provisioner, paths := range provisioners {
+			for path := range paths {
+				if _, err := Explain(kind, provisioner, path); err != nil {
+					t.Errorf("%s %s: %v", kind, provisioner, err)
+				}
+			}
+		}
+	}
+}
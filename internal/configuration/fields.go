@@ -0,0 +1,427 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"reflect"
+
+	bootstrapcfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+// field documents a field of the configuration files
+type field struct {
+	description string
+	// required marks the fields the provisioners need, the minimal templates only contain them
+	required bool
+	// defaultValue is the value used when the field is missing
+	defaultValue interface{}
+	// enum contains the accepted values of the field
+	enum []string
+	// example is the value written in the templates, the default value is used without it
+	example interface{}
+}
+
+// fields documents the fields of the configuration types by their yaml name
+var fields = map[reflect.Type]map[string]field{
+	reflect.TypeOf(Configuration{}): {
+		"apiVersion":  {description: "Version of the configuration file, the files without it are read as " + legacyAPIVersion, example: APIVersion},
+		"kind":        {description: "Kind of the configuration file", required: true},
+		"metadata":    {description: "Metadata of the resources", required: true},
+		"spec":        {description: "Specification of the resources created by the provisioner", required: true},
+		"executor":    {description: "Terraform executor configuration"},
+		"provisioner": {description: "Provisioner creating the resources", required: true},
+		"hooks":       {description: "Commands run around the phases of init, apply and destroy. A failing pre hook aborts the command"},
+	},
+	reflect.TypeOf(Metadata{}): {
+		"name":   {description: "Name of the project, used to identify its resources", required: true, example: "my-project"},
+		"labels": {description: "Labels of the project, added to the tags of the cloud resources. The values can be strings, numbers or booleans", example: map[string]interface{}{"environment": "dev"}},
+	},
+	reflect.TypeOf(TerraformExecutor{}): {
+		"state": {description: "Terraform state configuration"},
+	},
+	reflect.TypeOf(Hooks{}): {
+		"preInit":     {description: "Commands run by init once the project directory is created, before terraform is initialized"},
+		"preApply":    {description: "Commands run by apply before terraform is initialized, skipped with --dry-run"},
+		"postApply":   {description: "Commands run by apply once the outputs are saved, skipped with --dry-run"},
+		"preDestroy":  {description: "Commands run by destroy before terraform is initialized"},
+		"postDestroy": {description: "Commands run by destroy once the resources are destroyed"},
+		"timeout":     {description: "Timeout of every command, as 30s or 10m", defaultValue: defaultHookTimeout},
+	},
+	reflect.TypeOf(StateConfiguration{}): {
+		"backend": {description: "Terraform backend storing the state. See https://www.terraform.io/docs/configuration/backend.html", defaultValue: "local"},
+		"config":  {description: "Terraform backend configuration parameters", example: map[string]string{"path": "workdir/terraform.state"}},
+	},
+
+	reflect.TypeOf(bootstrapcfg.AWS{}): {
+		"networkCIDR":         {description: "VPC network CIDR", required: true, example: "10.0.0.0/16"},
+		"publicSubnetsCIDRs":  {description: "Public subnet CIDRs, inside the networkCIDR", required: true, example: []string{"10.0.1.0/24", "10.0.2.0/24", "10.0.3.0/24"}},
+		"privateSubnetsCIDRs": {description: "Private subnet CIDRs, inside the networkCIDR", required: true, example: []string{"10.0.101.0/24", "10.0.102.0/24", "10.0.103.0/24"}},
+		"vpn":                 {description: "VPN servers giving access to the private subnets", required: true},
+		"tags":                {description: "Tags added to all the resources, replacing the metadata labels with the same key", example: map[string]string{"environment": "dev"}},
+	},
+	reflect.TypeOf(bootstrapcfg.AWSVPN{}): vpnFields("t3.micro", "EC2 instance type of the VPN servers"),
+	reflect.TypeOf(bootstrapcfg.GCPVPN{}): vpnFields("n1-standard-1", "GCP instance type of the VPN servers"),
+	reflect.TypeOf(bootstrapcfg.GCP{}): {
+		"publicSubnetsCIDRs":  {description: "Public subnet CIDRs", required: true, example: []string{"10.0.1.0/24"}},
+		"privateSubnetsCIDRs": {description: "Private subnet CIDRs", required: true, example: []string{"10.0.101.0/24"}},
+		"clusterNetwork":      {description: "Subnetworks of the GKE cluster", required: true},
+		"vpn":                 {description: "VPN servers giving access to the private subnets", required: true},
+		"tags":                {description: "Tags added to all the resources, replacing the metadata labels with the same key", example: map[string]string{"environment": "dev"}},
+	},
+	reflect.TypeOf(bootstrapcfg.GCPClusterNetwork{}): {
+		"subnetworkCIDR":        {description: "CIDR of the cluster nodes subnetwork", required: true, example: "10.1.0.0/16"},
+		"controlPlaneCIDR":      {description: "CIDR of the GKE control plane, a /28 network", defaultValue: "10.0.0.0/28"},
+		"podSubnetworkCIDR":     {description: "CIDR of the pods subnetwork", required: true, example: "10.2.0.0/16"},
+		"serviceSubnetworkCIDR": {description: "CIDR of the services subnetwork", required: true, example: "10.3.0.0/16"},
+	},
+
+	reflect.TypeOf(clustercfg.EKS{}): {
+		"version":      {description: "Kubernetes version of the EKS control plane", required: true, example: "1.20"},
+		"network":      {description: "ID of the VPC hosting the cluster", required: true, example: "vpc-0123456789abcdef0"},
+		"subnetworks":  {description: "IDs of the subnets hosting the cluster", required: true, example: []string{"subnet-0123456789abcdef0"}},
+		"dmzCIDRRange": {description: "Network CIDRs the cluster control plane is accessible from, a single CIDR or a list", required: true, example: []string{"10.0.0.0/16"}},
+		"sshPublicKey": {description: "Cluster administrator public ssh key, used to access the cluster nodes", required: true, example: "ssh-rsa AAAA... admin@example.com"},
+		"nodePools":    {description: "Node pools of the cluster"},
+		"tags":         {description: "Tags added to all the resources, replacing the metadata labels with the same key", example: map[string]string{"environment": "dev"}},
+		"auth":         {description: "Additional AWS accounts, users and roles added to the aws-auth configmap"},
+	},
+	reflect.TypeOf(clustercfg.EKSAuth{}): {
+		"additionalAccounts": {description: "Additional AWS account numbers", example: []string{"777777777777"}},
+		"users": {description: "Additional IAM users, with their userarn", example: []clustercfg.EKSAuthData{
+			{Username: "admin", Groups: []string{"system:masters"}, UserARN: "arn:aws:iam::777777777777:user/admin"},
+		}},
+		"roles": {description: "Additional IAM roles, with their rolearn", example: []clustercfg.EKSAuthData{
+			{Username: "admin", Groups: []string{"system:masters"}, RoleARN: "arn:aws:iam::777777777777:role/admin"},
+		}},
+	},
+	reflect.TypeOf(clustercfg.EKSAuthData{}): {
+		"username": {description: "Kubernetes username of the IAM user or role", required: true, example: "admin"},
+		"groups":   {description: "Kubernetes groups of the IAM user or role", required: true, example: []string{"system:masters"}},
+		"userarn":  {description: "ARN of the IAM user, only for the users"},
+		"rolearn":  {description: "ARN of the IAM role, only for the roles"},
+	},
+	reflect.TypeOf(clustercfg.EKSNodePool{}): nodePoolFields(map[string]field{
+		"version":      {description: "Kubernetes version of the nodes, empty to use the control plane one", example: "1.20"},
+		"instanceType": {description: "EC2 instance type of the nodes", required: true, example: "t3.large"},
+		"os":           {description: "AMI ID of the nodes, empty to use the default EKS one"},
+		"targetGroups": {description: "ARNs of the load balancer target groups the nodes are registered to"},
+		"maxPods":      {description: "Maximum number of pods per node, empty to use the EKS default", example: 110},
+		"subnetworks":  {description: "IDs of the subnets hosting the nodes, empty to use the cluster ones", example: []string{"subnet-0123456789abcdef0"}},
+	}),
+	reflect.TypeOf(clustercfg.EKSNodePoolFwRule{}): firewallRuleFields(),
+
+	reflect.TypeOf(clustercfg.GKE{}): {
+		"version":                        {description: "Kubernetes version of the GKE control plane", required: true, example: "1.20.9-gke.701"},
+		"network":                        {description: "Name of the network hosting the cluster", required: true, example: "my-network"},
+		"networkProjectID":               {description: "Project ID of the shared VPC host, for shared VPC support"},
+		"controlPlaneCIDR":               {description: "CIDR of the GKE control plane, a /28 network", defaultValue: "10.0.0.0/28"},
+		"additionalFirewallRules":        {description: "Create additional firewall rules", defaultValue: true},
+		"additionalClusterFirewallRules": {description: "Create the additional firewall rules of the upstream GKE module", defaultValue: false},
+		"disableDefaultSNAT":             {description: "Disable the default SNAT to support the private use of public IP addresses", defaultValue: false},
+		"subnetworks":                    {description: "Names of the cluster nodes, pods and services subnetworks, in this order", required: true, example: []string{"my-cluster-subnet", "my-cluster-pod-subnet", "my-cluster-service-subnet"}},
+		"dmzCIDRRange":                   {description: "Network CIDRs the cluster control plane is accessible from, a single CIDR or a list", required: true, example: []string{"10.0.0.0/8"}},
+		"sshPublicKey":                   {description: "Cluster administrator public ssh key, used to access the cluster nodes", required: true, example: "ssh-rsa AAAA... admin@example.com"},
+		"nodePools":                      {description: "Node pools of the cluster"},
+		"tags":                           {description: "Tags added to all the resources, replacing the metadata labels with the same key", example: map[string]string{"environment": "dev"}},
+	},
+	reflect.TypeOf(clustercfg.GKENodePool{}): nodePoolFields(map[string]field{
+		"version":      {description: "Kubernetes version of the nodes, empty to use the control plane one", example: "1.20.9-gke.701"},
+		"instanceType": {description: "GCP machine type of the nodes", required: true, example: "n1-standard-2"},
+		"os":           {description: "Image type of the nodes", example: "COS"},
+		"maxPods":      {description: "Maximum number of pods per node, empty to use the GKE default", example: 110},
+		"subnetworks":  {description: "Zones hosting the nodes, empty to use all the zones of the region", example: []string{"europe-west1-b"}},
+	}),
+	reflect.TypeOf(clustercfg.GKENodePoolFwRule{}): firewallRuleFields(),
+
+	reflect.TypeOf(clustercfg.VSphere{}): {
+		"version":              {description: "Kubernetes version of the cluster", required: true, example: "1.20.5"},
+		"controlPlaneEndpoint": {description: "Kubernetes control plane endpoint, empty to use the VIP of the load balancer", example: "my-cluster.localdomain"},
+		"etcd":                 {description: "etcd configuration"},
+		"oidc":                 {description: "OIDC authentication of the Kubernetes API server"},
+		"cri":                  {description: "Container runtime configuration"},
+		"environmentName":      {description: "Environment name of the cluster", required: true, example: "production"},
+		"config":               {description: "vCenter resources hosting the virtual machines", required: true},
+		"networkConfig":        {description: "Network of the virtual machines", required: true},
+		"boundary":             {description: "Enable the boundary targets on all the nodes", defaultValue: false},
+		"lbNode":               {description: "HAProxy load balancer virtual machines", required: true},
+		"masterNode":           {description: "Kubernetes master nodes", required: true},
+		"infraNode":            {description: "Kubernetes infra nodes", required: true},
+		"nodePools": {description: "Additional node pools, every one with its role", example: []clustercfg.VSphereKubeNode{
+			{Role: "applications", Count: 1, CPU: 2, MemSize: 8192, DiskSize: 100, Template: "ubuntu-20.04", Labels: map[string]string{"node-kind": "applications"}},
+		}},
+		"clusterPODCIDR": {description: "CIDR of the pods, disjoint from the clusterCIDR", required: true, example: "172.21.0.0/16"},
+		"clusterSVCCIDR": {description: "CIDR of the services, disjoint from the clusterCIDR", required: true, example: "172.23.0.0/16"},
+		"clusterCIDR":    {description: "CIDR of the virtual machines network, used to calculate their IPs", required: true, example: "10.2.0.0/16"},
+		"sshPublicKeys":  {description: "Paths of the public ssh keys authorized to connect to the virtual machines", example: []string{"/home/admin/.ssh/id_rsa.pub"}},
+	},
+	reflect.TypeOf(clustercfg.VSphereETCDConfig{}): {
+		"version": {description: "etcd version", defaultValue: "v3.4.15"},
+	},
+	reflect.TypeOf(clustercfg.VSphereOIDCConfig{}): {
+		"issuerURL": {description: "Issuer URL of the OIDC provider", example: "https://dex.example.com/"},
+		"clientID":  {description: "Client ID of the OIDC provider", example: "oidc-auth-client"},
+		"caFile":    {description: "CA certificate of the OIDC provider", example: "/etc/pki/ca-trust/source/anchors/example.com.cer"},
+	},
+	reflect.TypeOf(clustercfg.VSphereCRIConfig{}): {
+		"version": {description: "Container runtime version, empty to use the default one", example: "18.06.2.ce"},
+		"dns":     {description: "DNS servers of the container runtime", example: []string{"10.2.0.1"}},
+		"proxy":   {description: "Proxy environment variables of the container runtime", example: `"HTTP_PROXY=http://proxy.example.com:8080" "NO_PROXY=.example.com"`},
+		"mirrors": {description: "Registry mirrors of the container runtime", example: []string{"https://mirror.gcr.io"}},
+	},
+	reflect.TypeOf(clustercfg.VSphereConfig{}): {
+		"datacenterName": {description: "Datacenter name as seen in vCenter", required: true, example: "westeros"},
+		"datastore":      {description: "Datastore hosting the virtual machines", required: true, example: "main"},
+		"esxiHosts":      {description: "ESXi hosts where the virtual machines are created", required: true, example: []string{"host1", "host2", "host3"}},
+	},
+	reflect.TypeOf(clustercfg.VSphereNetworkConfig{}): {
+		"name":        {description: "vSphere network of the virtual machines", required: true, example: "main-network"},
+		"gateway":     {description: "Default gateway of the virtual machines, inside the clusterCIDR. Empty to use the first IP of the network", example: "10.2.0.1"},
+		"nameservers": {description: "Nameservers of the virtual machines, empty to use the gateway", example: []string{"10.2.0.1"}},
+		"domain":      {description: "DNS search domain of the virtual machines", defaultValue: "localdomain"},
+		"ipOffset":    {description: "Offset added to every IP of the virtual machines, to deploy multiple clusters in the same network", defaultValue: 0},
+	},
+	reflect.TypeOf(clustercfg.VSphereKubeLoadBalancer{}): {
+		"count":            {description: "Number of load balancer virtual machines", required: true, example: 1},
+		"template":         {description: "Template the virtual machines are cloned from", required: true, example: "ubuntu-20.04"},
+		"customScriptPath": {description: "Local path of a script run on the first boot", example: "./lb-first-boot.sh"},
+	},
+	reflect.TypeOf(clustercfg.VSphereKubeNode{}): {
+		"role":             {description: "Role of the nodes, required by the node pools"},
+		"count":            {description: "Number of nodes", required: true, example: 1},
+		"cpu":              {description: "vCPU count of every node", required: true, example: 2},
+		"memSize":          {description: "Memory of every node in MB", required: true, example: 8192},
+		"diskSize":         {description: "Disk size of every node in GB", required: true, example: 100},
+		"template":         {description: "Template the virtual machines are cloned from", required: true, example: "ubuntu-20.04"},
+		"labels":           {description: "Kubernetes labels of the nodes", example: map[string]string{"environment": "dev"}},
+		"taints":           {description: "Kubernetes taints of the nodes", example: []string{"key1=value1:NoSchedule"}},
+		"customScriptPath": {description: "Local path of a script run on the first boot", example: "./node-first-boot.sh"},
+	},
+}
+
+// vpnFields documents the VPN of the bootstrap provisioners
+func vpnFields(instanceType, instanceTypeDescription string) map[string]field {
+	return map[string]field{
+		"instances":     {description: "Number of VPN servers", defaultValue: 1},
+		"port":          {description: "Port of the VPN servers", defaultValue: 1194},
+		"instanceType":  {description: instanceTypeDescription, defaultValue: instanceType},
+		"diskSize":      {description: "Disk size of the VPN servers in GB", defaultValue: 50},
+		"operatorName":  {description: "SSH user of the VPN servers", defaultValue: "sighup"},
+		"dhParamsBits":  {description: "Diffie-Hellman key size in bits", defaultValue: 2048},
+		"subnetCIDR":    {description: "CIDR of the VPN clients, disjoint from the network CIDRs", required: true, example: "192.168.200.0/24"},
+		"sshUsers":      {description: "GitHub users whose public keys are authorized to log into the VPN servers", required: true, example: []string{"github-user"}},
+		"operatorCIDRs": {description: "CIDRs allowed to log into the VPN servers via SSH", defaultValue: []string{"0.0.0.0/0"}},
+	}
+}
+
+// nodePoolFields documents the node pools of the cloud provisioners, extended with their own fields
+func nodePoolFields(own map[string]field) map[string]field {
+	f := map[string]field{
+		"name":                    {description: "Name of the node pool", required: true, example: "my-node-pool"},
+		"minSize":                 {description: "Minimum number of nodes", example: 1},
+		"maxSize":                 {description: "Maximum number of nodes", required: true, example: 3},
+		"spotInstance":            {description: "Use spot or preemptible instances", defaultValue: false},
+		"volumeSize":              {description: "Disk size of every node in GB", required: true, example: 50},
+		"labels":                  {description: "Kubernetes labels of the nodes", example: map[string]string{"environment": "dev"}},
+		"taints":                  {description: "Kubernetes taints of the nodes", example: []string{"key1=value1:NoSchedule"}},
+		"tags":                    {description: "Tags added to the node pool resources", example: map[string]string{"environment": "dev"}},
+		"additionalFirewallRules": {description: "Additional firewall rules of the nodes"},
+	}
+	for name, own := range own {
+		f[name] = own
+	}
+	return f
+}
+
+// firewallRuleFields documents the additional firewall rules of the node pools
+func firewallRuleFields() map[string]field {
+	return map[string]field{
+		"name":      {description: "Name of the rule", required: true, example: "dns"},
+		"direction": {description: "Direction of the traffic", required: true, enum: []string{"ingress", "egress"}, example: "ingress"},
+		"cidrBlock": {description: "CIDR the rule applies to", required: true, example: "0.0.0.0/0"},
+		"protocol":  {description: "Protocol of the traffic", required: true, example: "UDP"},
+		"ports":     {description: "Port range of the rule, as from-to", required: true, example: "53-53"},
+		"tags":      {description: "Tags added to the rule", example: map[string]string{"allow": "dns"}},
+	}
+}
+
+// lookupField returns the documentation of a field of a configuration type
+func lookupField(t reflect.Type, name string) field {
+	return fields[t][name]
+}
+
+// variables maps the fields of every kind and provisioner to the terraform variables they set, by their path without indexes
+var variables = map[string]map[string]map[string]string{
+	"Bootstrap": {
+		"aws": merge(vpnVariables(), map[string]string{
+			"metadata.name":            "name",
+			"spec.networkCIDR":         "network_cidr",
+			"spec.publicSubnetsCIDRs":  "public_subnetwork_cidrs",
+			"spec.privateSubnetsCIDRs": "private_subnetwork_cidrs",
+			"spec.tags":                "tags",
+			"metadata.labels":          "tags",
+		}),
+		"gcp": merge(vpnVariables(), map[string]string{
+			"metadata.name":                             "name",
+			"spec.publicSubnetsCIDRs":                   "public_subnetwork_cidrs",
+			"spec.privateSubnetsCIDRs":                  "private_subnetwork_cidrs",
+			"spec.clusterNetwork.subnetworkCIDR":        "cluster_subnetwork_cidr",
+			"spec.clusterNetwork.controlPlaneCIDR":      "cluster_control_plane_cidr_block",
+			"spec.clusterNetwork.podSubnetworkCIDR":     "cluster_pod_subnetwork_cidr",
+			"spec.clusterNetwork.serviceSubnetworkCIDR": "cluster_service_subnetwork_cidr",
+			"spec.tags":       "tags",
+			"metadata.labels": "tags",
+		}),
+	},
+	"Cluster": {
+		"eks": merge(cloudClusterVariables(), nodePoolVariables(), map[string]string{
+			"spec.nodePools.targetGroups":  "node_pools[].eks_target_group_arns",
+			"spec.auth.additionalAccounts": "eks_map_accounts",
+			"spec.auth.users":              "eks_map_users",
+			"spec.auth.users.username":     "eks_map_users[].username",
+			"spec.auth.users.groups":       "eks_map_users[].groups",
+			"spec.auth.users.userarn":      "eks_map_users[].userarn",
+			"spec.auth.roles":              "eks_map_roles",
+			"spec.auth.roles.username":     "eks_map_roles[].username",
+			"spec.auth.roles.groups":       "eks_map_roles[].groups",
+			"spec.auth.roles.rolearn":      "eks_map_roles[].rolearn",
+		}),
+		"gke": merge(cloudClusterVariables(), nodePoolVariables(), map[string]string{
+			"spec.networkProjectID":               "gke_network_project_id",
+			"spec.controlPlaneCIDR":               "gke_master_ipv4_cidr_block",
+			"spec.additionalFirewallRules":        "gke_add_additional_firewall_rules",
+			"spec.additionalClusterFirewallRules": "gke_add_cluster_firewall_rules",
+			"spec.disableDefaultSNAT":             "gke_disable_default_snat",
+		}),
+		"vsphere": merge(vsphereNodeVariables("spec.masterNode", "kube_master_"), vsphereNodeVariables("spec.infraNode", "kube_infra_"), map[string]string{
+			"metadata.name":                  "name",
+			"metadata.labels":                "tags",
+			"spec.version":                   "kube_version",
+			"spec.controlPlaneEndpoint":      "kube_control_plane_endpoint",
+			"spec.etcd.version":              "etcd_version",
+			"spec.oidc.issuerURL":            "oidc_issuer_url",
+			"spec.oidc.clientID":             "oidc_client_id",
+			"spec.oidc.caFile":               "oidc_ca_file",
+			"spec.cri.version":               "cri_version",
+			"spec.cri.dns":                   "cri_dns",
+			"spec.cri.proxy":                 "cri_proxy",
+			"spec.cri.mirrors":               "cri_mirrors",
+			"spec.environmentName":           "env",
+			"spec.config.datacenterName":     "datacenter",
+			"spec.config.datastore":          "datastore",
+			"spec.config.esxiHosts":          "esxihosts",
+			"spec.networkConfig.name":        "network",
+			"spec.networkConfig.gateway":     "net_gateway",
+			"spec.networkConfig.nameservers": "net_nameservers",
+			"spec.networkConfig.domain":      "net_domain",
+			"spec.networkConfig.ipOffset":    "ip_offset",
+			"spec.boundary":                  "enable_boundary_targets",
+			"spec.lbNode.count":              "kube_lb_count",
+			"spec.lbNode.template":           "kube_lb_template",
+			"spec.lbNode.customScriptPath":   "kube_lb_custom_script_path",
+			"spec.nodePools":                 "node_pools",
+			"spec.nodePools.role":            "node_pools[].role",
+			"spec.nodePools.count":           "node_pools[].count",
+			"spec.nodePools.cpu":             "node_pools[].cpu",
+			"spec.nodePools.memSize":         "node_pools[].memory",
+			"spec.nodePools.diskSize":        "node_pools[].disk_size",
+			"spec.nodePools.template":        "node_pools[].template",
+			"spec.nodePools.labels":          "node_pools[].labels",
+			"spec.nodePools.taints":          "node_pools[].taints",
+			"spec.clusterPODCIDR":            "kube_pod_cidr",
+			"spec.clusterSVCCIDR":            "kube_svc_cidr",
+			"spec.clusterCIDR":               "net_cidr",
+			"spec.sshPublicKeys":             "ssh_public_keys",
+		}),
+	},
+}
+
+func vpnVariables() map[string]string {
+	return map[string]string{
+		"spec.vpn.instances":     "vpn_instances",
+		"spec.vpn.port":          "vpn_port",
+		"spec.vpn.instanceType":  "vpn_instance_type",
+		"spec.vpn.diskSize":      "vpn_instance_disk_size",
+		"spec.vpn.operatorName":  "vpn_operator_name",
+		"spec.vpn.dhParamsBits":  "vpn_dhparams_bits",
+		"spec.vpn.subnetCIDR":    "vpn_subnetwork_cidr",
+		"spec.vpn.sshUsers":      "vpn_ssh_users",
+		"spec.vpn.operatorCIDRs": "vpn_operator_cidrs",
+	}
+}
+
+func cloudClusterVariables() map[string]string {
+	return map[string]string{
+		"metadata.name":     "cluster_name",
+		"spec.version":      "cluster_version",
+		"spec.network":      "network",
+		"spec.subnetworks":  "subnetworks",
+		"spec.dmzCIDRRange": "dmz_cidr_range",
+		"spec.sshPublicKey": "ssh_public_key",
+		"spec.tags":         "tags",
+		"metadata.labels":   "tags",
+	}
+}
+
+func nodePoolVariables() map[string]string {
+	v := map[string]string{"spec.nodePools": "node_pools"}
+	for field, variable := range map[string]string{
+		"name":         "name",
+		"version":      "version",
+		"minSize":      "min_size",
+		"maxSize":      "max_size",
+		"instanceType": "instance_type",
+		"os":           "os",
+		"maxPods":      "max_pods",
+		"spotInstance": "spot_instance",
+		"volumeSize":   "volume_size",
+		"labels":       "labels",
+		"taints":       "taints",
+		"subnetworks":  "subnetworks",
+		"tags":         "tags",
+	} {
+		v["spec.nodePools."+field] = "node_pools[]." + variable
+	}
+	v["spec.nodePools.additionalFirewallRules"] = "node_pools[].additional_firewall_rules"
+	for field, variable := range map[string]string{
+		"name":      "name",
+		"direction": "direction",
+		"cidrBlock": "cidr_block",
+		"protocol":  "protocol",
+		"ports":     "ports",
+		"tags":      "tags",
+	} {
+		v["spec.nodePools.additionalFirewallRules."+field] = "node_pools[].additional_firewall_rules[]." + variable
+	}
+	return v
+}
+
+// vsphereNodeVariables maps the master and infra nodes fields, the variables differ only by their prefix
+func vsphereNodeVariables(path, prefix string) map[string]string {
+	v := map[string]string{}
+	for field, variable := range map[string]string{
+		"count":            "count",
+		"cpu":              "cpu",
+		"memSize":          "mem",
+		"diskSize":         "disk_size",
+		"template":         "template",
+		"labels":           "labels",
+		"taints":           "taints",
+		"customScriptPath": "custom_script_path",
+	} {
+		v[path+"."+field] = prefix + variable
+	}
+	return v
+}
+
+func merge(maps ...map[string]string) map[string]string {
+	merged := map[string]string{}
+	for _, m := range maps {
+		for k, v := range m {
+			merged[k] = v
+		}
+	}
+	return merged
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultHookTimeout is the timeout of the hook commands
+const defaultHookTimeout = "5m"
+
+// Hooks are the commands run around the phases of the lifecycle of a project, with the project path,
+// the kubeconfig path and the terraform outputs as environment variables
+type Hooks struct {
+	PreInit     []string `yaml:"preInit"`
+	PreApply    []string `yaml:"preApply"`
+	PostApply   []string `yaml:"postApply"`
+	PreDestroy  []string `yaml:"preDestroy"`
+	PostDestroy []string `yaml:"postDestroy"`
+	Timeout     string   `yaml:"timeout"`
+}
+
+// TimeoutDuration returns the timeout of every hook command, the default one when it is not set
+func (h Hooks) TimeoutDuration() (time.Duration, error) {
+	timeout := h.Timeout
+	if timeout == "" {
+		timeout = defaultHookTimeout
+	}
+	d, err := time.ParseDuration(timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %s, expected a duration as 30s or 10m", timeout)
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid duration %s, expected a positive duration", timeout)
+	}
+	return d, nil
+}
+
+// validateHooks checks the timeout of the hooks
+func validateHooks(hooks Hooks) ValidationErrors {
+	if _, err := hooks.TimeoutDuration(); err != nil {
+		return ValidationErrors{{Path: "hooks.timeout", Message: err.Error()}}
+	}
+	return nil
+}
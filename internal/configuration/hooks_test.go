@@ -0,0 +1,32 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHooksTimeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		want    time.Duration
+		wantErr bool
+	}{
+		{timeout: "", want: 5 * time.Minute},
+		{timeout: "30s", want: 30 * time.Second},
+		{timeout: "soon", wantErr: true},
+		{timeout: "-1m", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := Hooks{Timeout: tt.timeout}.TimeoutDuration()
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("TimeoutDuration() of %q = %v, %v, want %v", tt.timeout, got, err, tt.want)
+		}
+		if errs := validateHooks(Hooks{Timeout: tt.timeout}); (len(errs) > 0) != tt.wantErr {
+			t.Errorf("validateHooks() of %q = %v", tt.timeout, errs)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	filePrefix = "file://"
+	sopsPrefix = "sops://"
+)
+
+// envPattern matches ${VAR}, ${VAR:-default} and the $$ escape
+var envPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// sopsDecrypt decrypts a sops file, the age keys are read by sops from SOPS_AGE_KEY_FILE
+var sopsDecrypt = func(path string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("sops", "--decrypt", path)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("sops could not decrypt %s: %v %s", path, err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// interpolator resolves the environment variables, the files and the secrets referenced by the configuration values
+type interpolator struct {
+	// dir is the base directory of the relative file references
+	dir     string
+	secrets map[string]*yamlv3.Node
+	errs    ValidationErrors
+	changed bool
+}
+
+// interpolate resolves the references of a document in place, returning whether any value changed.
+// The hooks are not resolved
+func interpolate(root *yamlv3.Node, dir string) (bool, error) {
+	i := &interpolator{dir: dir, secrets: map[string]*yamlv3.Node{}}
+	i.walk(root, "")
+	if len(i.errs) > 0 {
+		return i.changed, i.errs
+	}
+	return i.changed, nil
+}
+
+func (i *interpolator) walk(node *yamlv3.Node, path string) {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			// the hook commands reach the shell as they are written, expanding their variables at run time
+			if path == "" && node.Content[j].Value == "hooks" {
+				continue
+			}
+			i.walk(node.Content[j+1], joinPath(path, node.Content[j].Value))
+		}
+	case yamlv3.SequenceNode:
+		for j, item := range node.Content {
+			i.walk(item, fmt.Sprintf("%s[%d]", path, j))
+		}
+	case yamlv3.ScalarNode:
+		if node.Tag != "!!str" {
+			return
+		}
+		value, err := i.resolve(node.Value)
+		if err != nil {
+			i.errs = append(i.errs, ValidationError{Path: path, Line: node.Line, Column: node.Column, Message: err.Error()})
+			return
+		}
+		if value != node.Value {
+			node.Value = value
+			retag(node)
+			i.changed = true
+		}
+	}
+}
+
+// resolve expands the environment variables of a value and then replaces the file and secret references with their content
+func (i *interpolator) resolve(value string) (string, error) {
+	var err error
+	value = envPattern.ReplaceAllStringFunc(value, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		groups := envPattern.FindStringSubmatch(match)
+		if env, ok := os.LookupEnv(groups[1]); ok && (env != "" || groups[2] == "") {
+			return env
+		}
+		if groups[2] != "" {
+			return groups[3]
+		}
+		if err == nil {
+			err = fmt.Errorf("environment variable %s is not set", groups[1])
+		}
+		return match
+	})
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case strings.HasPrefix(value, filePrefix):
+		content, err := ioutil.ReadFile(i.path(strings.TrimPrefix(value, filePrefix)))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\n"), nil
+	case strings.HasPrefix(value, sopsPrefix):
+		return i.secret(strings.TrimPrefix(value, sopsPrefix))
+	}
+	return value, nil
+}
+
+// secret returns the value of a sops://file#key reference, or the whole decrypted file without the key
+func (i *interpolator) secret(reference string) (string, error) {
+	file := reference
+	key := ""
+	if hash := strings.Index(reference, "#"); hash >= 0 {
+		file, key = reference[:hash], reference[hash+1:]
+	}
+	file = i.path(file)
+	if key == "" {
+		content, err := sopsDecrypt(file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\n"), nil
+	}
+	doc, ok := i.secrets[file]
+	if !ok {
+		content, err := sopsDecrypt(file)
+		if err != nil {
+			return "", err
+		}
+		var decoded yamlv3.Node
+		if err = yamlv3.Unmarshal(content, &decoded); err != nil || len(decoded.Content) == 0 {
+			return "", fmt.Errorf("%s is not a yaml or json file", file)
+		}
+		doc = decoded.Content[0]
+		i.secrets[file] = doc
+	}
+	value := doc
+	for _, name := range strings.Split(key, ".") {
+		if value = mappingValue(value, name); value == nil {
+			return "", fmt.Errorf("secret %s not found in %s", key, file)
+		}
+	}
+	if value.Kind != yamlv3.ScalarNode {
+		return "", fmt.Errorf("secret %s in %s is not a single value", key, file)
+	}
+	return value.Value, nil
+}
+
+func (i *interpolator) path(file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(i.dir, file)
+}
+
+// retag sets the type of a resolved plain value as if it was written in the file
+func retag(node *yamlv3.Node) {
+	node.Tag = "!!str"
+	if node.Style != 0 {
+		return
+	}
+	var probe yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(node.Value), &probe); err == nil && len(probe.Content) == 1 {
+		if value := probe.Content[0]; value.Kind == yamlv3.ScalarNode && value.Style == 0 {
+			node.Tag = value.Tag
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+func TestParseInterpolation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"id_rsa.pub":      "ssh-rsa AAAA demo\n",
+		"secrets.enc.yml": "backend:\n  bucket: secret-bucket\n  region: eu-west-1\n",
+		"cluster.yml": `apiVersion: furyctl.sighup.io/v1alpha2
+kind: Cluster
+metadata:
+  name: ${CLUSTER_NAME}
+provisioner: eks
+executor:
+  state:
+    backend: s3
+    config:
+      bucket: sops://secrets.enc.yml#backend.bucket
+      key: $${not-interpolated}
+      region: ${AWS_REGION:-eu-central-1}
+spec:
+  version: "1.18"
+  network: vpc-${ENVIRONMENT}
+  sshPublicKey: file://id_rsa.pub
+  nodePools:
+    - name: one
+      maxPods: ${MAX_PODS}
+hooks:
+  postApply:
+    - kubectl --kubeconfig "${FURYCTL_KUBECONFIG}" label nodes --all environment=$${ENVIRONMENT}
+`,
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	decrypt := sopsDecrypt
+	defer func() { sopsDecrypt = decrypt }()
+	sopsDecrypt = func(path string) ([]byte, error) {
+		return ioutil.ReadFile(path)
+	}
+
+	os.Setenv("CLUSTER_NAME", "demo")
+	os.Setenv("ENVIRONMENT", "production")
+	os.Setenv("MAX_PODS", "58")
+	os.Unsetenv("AWS_REGION")
+	os.Unsetenv("FURYCTL_KUBECONFIG")
+	defer os.Unsetenv("CLUSTER_NAME")
+	defer os.Unsetenv("ENVIRONMENT")
+	defer os.Unsetenv("MAX_PODS")
+
+	config, err := Parse(readFile(t, filepath.Join(dir, "cluster.yml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantState := map[string]string{"bucket": "secret-bucket", "key": "${not-interpolated}", "region": "eu-central-1"}
+	if config.Metadata.Name != "demo" || !reflect.DeepEqual(config.Executor.StateConfiguration.Config, wantState) {
+		t.Errorf("Parse() = %+v", config)
+	}
+	spec := config.Spec.(clustercfg.EKS)
+	if spec.Network != "vpc-production" || spec.SSHPublicKey != "ssh-rsa AAAA demo" || spec.NodePools[0].MaxPods != 58 {
+		t.Errorf("Parse() spec = %+v", spec)
+	}
+	wantHooks := []string{`kubectl --kubeconfig "${FURYCTL_KUBECONFIG}" label nodes --all environment=$${ENVIRONMENT}`}
+	if !reflect.DeepEqual(config.Hooks.PostApply, wantHooks) {
+		t.Errorf("Parse() hooks = %v, want them as written %v", config.Hooks.PostApply, wantHooks)
+	}
+
+	os.Unsetenv("MAX_PODS")
+	sopsDecrypt = func(path string) ([]byte, error) {
+		return nil, fmt.Errorf("sops could not decrypt %s", filepath.Base(path))
+	}
+	_, err = Parse(readFile(t, filepath.Join(dir, "cluster.yml")))
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Parse() error = %v, want ValidationErrors", err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message))
+	}
+	want := []string{
+		"10:15: executor.state.config.bucket: sops could not decrypt secrets.enc.yml",
+		"19:16: spec.nodePools[0].maxPods: environment variable MAX_PODS is not set",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() errors =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestSecretReferences(t *testing.T) {
+	decrypt := sopsDecrypt
+	defer func() { sopsDecrypt = decrypt }()
+	decrypted := 0
+	sopsDecrypt = func(path string) ([]byte, error) {
+		decrypted++
+		return []byte(`{"aws": {"key": "AKIA", "tags": ["a"]}}`), nil
+	}
+	i := &interpolator{dir: "/config", secrets: map[string]*yamlv3.Node{}}
+	for reference, want := range map[string]string{
+		"secrets.json#aws.key":    "AKIA",
+		"secrets.json#aws.secret": "error: secret aws.secret not found in /config/secrets.json",
+		"secrets.json#aws.tags":   "error: secret aws.tags in /config/secrets.json is not a single value",
+	} {
+		got, err := i.secret(reference)
+		if err != nil {
+			got = "error: " + err.Error()
+		}
+		if got != want {
+			t.Errorf("secret(%s) = %s, want %s", reference, got, want)
+		}
+	}
+	if decrypted != 1 {
+		t.Errorf("secrets.json was decrypted %d times, want once", decrypted)
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"fmt"
+	"net"
+
+	bootstrapcfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+// gkeControlPlanePrefix is the only prefix length GKE accepts for the control plane network
+const gkeControlPlanePrefix = 28
+
+// network is a parsed CIDR with the path of the field it comes from
+type network struct {
+	path string
+	net  *net.IPNet
+}
+
+// networkValidator collects the errors found checking the network fields of a spec
+type networkValidator struct {
+	errs ValidationErrors
+}
+
+func (v *networkValidator) fail(path, format string, args ...interface{}) {
+	v.errs = append(v.errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// cidr parses an optional CIDR field, returning nil when it is empty or invalid
+func (v *networkValidator) cidr(path, value string) *network {
+	if value == "" {
+		return nil
+	}
+	_, n, err := net.ParseCIDR(value)
+	if err != nil {
+		v.fail(path, "%q is not a valid CIDR", value)
+		return nil
+	}
+	return &network{path: path, net: n}
+}
+
+func (v *networkValidator) cidrs(path string, values []string) []*network {
+	networks := []*network{}
+	for i, value := range values {
+		if n := v.cidr(fmt.Sprintf("%s[%d]", path, i), value); n != nil {
+			networks = append(networks, n)
+		}
+	}
+	return networks
+}
+
+// ip parses an optional IP field, returning nil when it is empty or invalid
+func (v *networkValidator) ip(path, value string) net.IP {
+	if value == "" {
+		return nil
+	}
+	ip := net.ParseIP(value)
+	if ip == nil {
+		v.fail(path, "%q is not a valid IP address", value)
+	}
+	return ip
+}
+
+// inside checks every network is contained in the parent one
+func (v *networkValidator) inside(parent *network, networks ...*network) {
+	if parent == nil {
+		return
+	}
+	for _, n := range networks {
+		if n == nil {
+			continue
+		}
+		ones, _ := n.net.Mask.Size()
+		parentOnes, _ := parent.net.Mask.Size()
+		if !parent.net.Contains(n.net.IP) || ones < parentOnes {
+			v.fail(n.path, "%s is not inside %s %s", n.net, parent.path, parent.net)
+		}
+	}
+}
+
+// disjoint checks no network overlaps another one
+func (v *networkValidator) disjoint(networks ...*network) {
+	for i, a := range networks {
+		if a == nil {
+			continue
+		}
+		for _, b := range networks[:i] {
+			if b != nil && (a.net.Contains(b.net.IP) || b.net.Contains(a.net.IP)) {
+				v.fail(a.path, "%s overlaps %s %s", a.net, b.path, b.net)
+			}
+		}
+	}
+}
+
+// ipInside checks an address belongs to the network
+func (v *networkValidator) ipInside(path string, ip net.IP, parent *network) {
+	if ip != nil && parent != nil && !parent.net.Contains(ip) {
+		v.fail(path, "%s is not inside %s %s", ip, parent.path, parent.net)
+	}
+}
+
+// validateNetwork checks the CIDRs and the addresses of a parsed spec are valid and consistent
+func validateNetwork(spec interface{}) ValidationErrors {
+	v := &networkValidator{}
+	switch s := spec.(type) {
+	case bootstrapcfg.AWS:
+		v.awsBootstrap(s)
+	case bootstrapcfg.GCP:
+		v.gcpBootstrap(s)
+	case clustercfg.EKS:
+		v.cidrs("spec.dmzCIDRRange", s.DMZCIDRRange.Values)
+		for i, pool := range s.NodePools {
+			for j, rule := range pool.AdditionalFirewallRules {
+				v.cidr(fmt.Sprintf("spec.nodePools[%d].additionalFirewallRules[%d].cidrBlock", i, j), rule.CIDRBlock)
+			}
+		}
+	case clustercfg.GKE:
+		v.gkeControlPlane(v.cidr("spec.controlPlaneCIDR", s.ControlPlaneCIDR))
+		v.cidrs("spec.dmzCIDRRange", s.DMZCIDRRange.Values)
+		for i, pool := range s.NodePools {
+			for j, rule := range pool.AdditionalFirewallRules {
+				v.cidr(fmt.Sprintf("spec.nodePools[%d].additionalFirewallRules[%d].cidrBlock", i, j), rule.CIDRBlock)
+			}
+		}
+	case clustercfg.VSphere:
+		v.vsphereCluster(s)
+	}
+	return v.errs
+}
+
+func (v *networkValidator) awsBootstrap(s bootstrapcfg.AWS) {
+	vpc := v.cidr("spec.networkCIDR", s.NetworkCIDR)
+	subnets := append(v.cidrs("spec.publicSubnetsCIDRs", s.PublicSubnetsCIDRs), v.cidrs("spec.privateSubnetsCIDRs", s.PrivateSubnetsCIDRs)...)
+	v.inside(vpc, subnets...)
+	v.disjoint(subnets...)
+	v.disjoint(vpc, v.cidr("spec.vpn.subnetCIDR", s.VPN.SubnetCIDR))
+	v.cidrs("spec.vpn.operatorCIDRs", s.VPN.OperatorCIDRs)
+}
+
+func (v *networkValidator) gcpBootstrap(s bootstrapcfg.GCP) {
+	subnets := append(v.cidrs("spec.publicSubnetsCIDRs", s.PublicSubnetsCIDRs), v.cidrs("spec.privateSubnetsCIDRs", s.PrivateSubnetsCIDRs)...)
+	controlPlane := v.cidr("spec.clusterNetwork.controlPlaneCIDR", s.ClusterNetwork.ControlPlaneCIDR)
+	v.gkeControlPlane(controlPlane)
+	ranges := append(subnets,
+		v.cidr("spec.clusterNetwork.subnetworkCIDR", s.ClusterNetwork.SubnetworkCIDR),
+		controlPlane,
+		v.cidr("spec.clusterNetwork.podSubnetworkCIDR", s.ClusterNetwork.PodSubnetworkCIDR),
+		v.cidr("spec.clusterNetwork.serviceSubnetworkCIDR", s.ClusterNetwork.ServiceSubnetworkCIDR),
+		v.cidr("spec.vpn.subnetCIDR", s.VPN.SubnetCIDR),
+	)
+	v.disjoint(ranges...)
+	v.cidrs("spec.vpn.operatorCIDRs", s.VPN.OperatorCIDRs)
+}
+
+func (v *networkValidator) gkeControlPlane(n *network) {
+	if n == nil {
+		return
+	}
+	if ones, _ := n.net.Mask.Size(); ones != gkeControlPlanePrefix {
+		v.fail(n.path, "%s must be a /%d network", n.net, gkeControlPlanePrefix)
+	}
+}
+
+func (v *networkValidator) vsphereCluster(s clustercfg.VSphere) {
+	cluster := v.cidr("spec.clusterCIDR", s.ClusterCIDR)
+	v.disjoint(cluster, v.cidr("spec.clusterPODCIDR", s.ClusterPODCIDR), v.cidr("spec.clusterSVCCIDR", s.ClusterSVCCIDR))
+	v.ipInside("spec.networkConfig.gateway", v.ip("spec.networkConfig.gateway", s.NetworkConfig.Gateway), cluster)
+	for i, nameserver := range s.NetworkConfig.Nameservers {
+		path := fmt.Sprintf("spec.networkConfig.nameservers[%d]", i)
+		v.ipInside(path, v.ip(path, nameserver), cluster)
+	}
+}
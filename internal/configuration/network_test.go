@@ -0,0 +1,116 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"reflect"
+	"testing"
+
+	bootstrapcfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+func TestValidateNetwork(t *testing.T) {
+	tests := []struct {
+		name string
+		spec interface{}
+		errs []string
+	}{
+		{
+			name: "sample aws bootstrap",
+			spec: sampleAWSBootstrap.Spec,
+		},
+		{
+			name: "aws subnet outside the vpc",
+			spec: bootstrapcfg.AWS{
+				NetworkCIDR:         "10.0.0.0/16",
+				PublicSubnetsCIDRs:  []string{"10.0.1.0/24"},
+				PrivateSubnetsCIDRs: []string{"10.1.1.0/24", "10.0.0.0/8"},
+				VPN:                 bootstrapcfg.AWSVPN{SubnetCIDR: "10.0.200.0/24"},
+			},
+			errs: []string{
+				"spec.privateSubnetsCIDRs[0]: 10.1.1.0/24 is not inside spec.networkCIDR 10.0.0.0/16",
+				"spec.privateSubnetsCIDRs[1]: 10.0.0.0/8 is not inside spec.networkCIDR 10.0.0.0/16",
+				"spec.privateSubnetsCIDRs[1]: 10.0.0.0/8 overlaps spec.publicSubnetsCIDRs[0] 10.0.1.0/24",
+				"spec.privateSubnetsCIDRs[1]: 10.0.0.0/8 overlaps spec.privateSubnetsCIDRs[0] 10.1.1.0/24",
+				"spec.vpn.subnetCIDR: 10.0.200.0/24 overlaps spec.networkCIDR 10.0.0.0/16",
+			},
+		},
+		{
+			name: "gcp invalid ranges",
+			spec: bootstrapcfg.GCP{
+				PublicSubnetsCIDRs: []string{"10.0.1.0/24", "10.0.1"},
+				ClusterNetwork: bootstrapcfg.GCPClusterNetwork{
+					ControlPlaneCIDR:      "10.0.0.0/24",
+					PodSubnetworkCIDR:     "10.1.0.0/16",
+					ServiceSubnetworkCIDR: "10.1.128.0/20",
+				},
+			},
+			errs: []string{
+				`spec.publicSubnetsCIDRs[1]: "10.0.1" is not a valid CIDR`,
+				"spec.clusterNetwork.controlPlaneCIDR: 10.0.0.0/24 must be a /28 network",
+				"spec.clusterNetwork.serviceSubnetworkCIDR: 10.1.128.0/20 overlaps spec.clusterNetwork.podSubnetworkCIDR 10.1.0.0/16",
+			},
+		},
+		{
+			name: "gke control plane",
+			spec: clustercfg.GKE{
+				ControlPlaneCIDR: "10.0.0.0/28",
+				DMZCIDRRange:     clustercfg.DMZCIDRRange{Values: []string{"0.0.0.0/0", "any"}},
+			},
+			errs: []string{`spec.dmzCIDRRange[1]: "any" is not a valid CIDR`},
+		},
+		{
+			name: "vsphere",
+			spec: clustercfg.VSphere{
+				ClusterCIDR:    "10.180.0.0/16",
+				ClusterPODCIDR: "172.21.0.0/16",
+				ClusterSVCCIDR: "172.21.128.0/17",
+				NetworkConfig: clustercfg.VSphereNetworkConfig{
+					Gateway:     "10.181.0.1",
+					Nameservers: []string{"10.180.0.2", "1.1.1.1", "dns"},
+				},
+			},
+			errs: []string{
+				"spec.clusterSVCCIDR: 172.21.128.0/17 overlaps spec.clusterPODCIDR 172.21.0.0/16",
+				"spec.networkConfig.gateway: 10.181.0.1 is not inside spec.clusterCIDR 10.180.0.0/16",
+				"spec.networkConfig.nameservers[1]: 1.1.1.1 is not inside spec.clusterCIDR 10.180.0.0/16",
+				`spec.networkConfig.nameservers[2]: "dns" is not a valid IP address`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := validateNetwork(tt.spec)
+			if got := messages(errs); !reflect.DeepEqual(got, tt.errs) {
+				t.Errorf("validateNetwork() errors =\n%v\nwant\n%v", got, tt.errs)
+			}
+		})
+	}
+}
+
+func TestParseInvalidNetwork(t *testing.T) {
+	path := "assets/aws-bootstrap-overlapping.yml"
+	_, err := Parse(readFile(t, path))
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Parse() error = %v, want ValidationErrors", err)
+	}
+	want := ValidationErrors{
+		{File: path, Path: "spec.privateSubnetsCIDRs[0]", Line: 11, Column: 7, Message: "10.1.101.0/24 is not inside spec.networkCIDR 10.0.0.0/16"},
+		{File: path, Path: "spec.publicSubnetsCIDRs[1]", Line: 9, Column: 7, Message: "10.0.1.128/25 overlaps spec.publicSubnetsCIDRs[0] 10.0.1.0/24"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("Parse() errors =\n%v\nwant\n%v", errs, want)
+	}
+}
+
+func messages(errs ValidationErrors) []string {
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Path+": "+err.Message)
+	}
+	return messages
+}
@@ -0,0 +1,260 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// document is a configuration file merged with its overlays
+type document struct {
+	node *yamlv3.Node
+	root *yamlv3.Node
+	// file is the path of the base configuration file
+	file string
+	// files contains the file every node comes from
+	files map[*yamlv3.Node]string
+}
+
+// Options configures how the configuration files are read
+type Options struct {
+	// Overlays are the locations of the files merged in order into the configuration file, read as Open does
+	Overlays []string
+	// AllowUnknownFields reports the fields unknown to this release as warnings instead of failing
+	AllowUnknownFields bool
+	// Warn receives the warnings found reading the configuration files, they are logged when it is nil
+	Warn func(ValidationError)
+}
+
+func (o Options) warn(w ValidationError) {
+	if o.Warn == nil {
+		log.Warn(w.Error())
+		return
+	}
+	o.Warn(w)
+}
+
+// load reads a configuration file from a reader and its overlays from their locations, converts them to the latest
+// version and merges them in order. The source names the configuration file in the messages.
+// When resolve is set the references of the values are resolved and the result is validated against its schema
+func load(r io.Reader, source string, opts Options, resolve bool) (*document, error) {
+	d := &document{files: map[*yamlv3.Node]string{}}
+	paths := make([]string, 0, len(opts.Overlays)+1)
+	docs := make([]*yamlv3.Node, 0, len(opts.Overlays)+1)
+	for i := 0; i <= len(opts.Overlays); i++ {
+		var content []byte
+		var err error
+		path := source
+		if i == 0 {
+			content, err = ioutil.ReadAll(r)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", source, err)
+			}
+		} else {
+			if opts.Overlays[i-1] == Stdin && contains(paths, stdinName) {
+				return nil, errors.New("the standard input can be read by one configuration file only")
+			}
+			if content, path, err = readLocation(opts.Overlays[i-1]); err != nil {
+				return nil, err
+			}
+		}
+		paths = append(paths, path)
+		var doc yamlv3.Node
+		if err = yamlv3.Unmarshal(content, &doc); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		if len(doc.Content) == 0 {
+			doc = yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{{Kind: yamlv3.MappingNode, Tag: "!!map"}}}
+		}
+		root := doc.Content[0]
+		if root.Kind != yamlv3.MappingNode {
+			return nil, fmt.Errorf("%s: the configuration file is not a yaml object", path)
+		}
+		if resolve {
+			if _, err = interpolate(root, sourceDir(path)); err != nil {
+				errs := err.(ValidationErrors)
+				errs.locate(root, nil, path)
+				return nil, errs
+			}
+		}
+		if i == 0 {
+			d.node, d.root, d.file = &doc, root, path
+		} else if scalarValue(root, "apiVersion") == "" {
+			// the overlays without an apiVersion use the one of the base file
+			if version := scalarValue(d.root, "apiVersion"); version != "" {
+				setAPIVersion(root, version)
+			}
+		}
+		docs = append(docs, root)
+	}
+
+	// the overlays do not need to repeat the kind and the provisioner of the base file
+	kind, provisioner := "", ""
+	for _, root := range docs {
+		if value := scalarValue(root, "kind"); value != "" {
+			kind = value
+		}
+		if value := scalarValue(root, "provisioner"); value != "" {
+			provisioner = value
+		}
+	}
+
+	for i, root := range docs {
+		path := paths[i]
+		// every file is checked in its own version, the required fields are checked once merged
+		if schema, err := VersionSchema(scalarValue(root, "apiVersion"), kind, provisioner); resolve && err == nil {
+			errs := schema.validateDocument(root, true)
+			if opts.AllowUnknownFields {
+				var unknown ValidationErrors
+				errs, unknown = errs.unknownFields()
+				for _, w := range unknown {
+					w.File = path
+					opts.warn(w)
+				}
+			}
+			if len(errs) > 0 {
+				for i := range errs {
+					errs[i].File = path
+				}
+				return nil, errs
+			}
+		}
+		// the position of the apiVersion is lost converting the document, the one added to the overlays has none
+		position := root
+		if key := keyIndex(root, "apiVersion"); key >= 0 && root.Content[key].Line > 0 {
+			position = root.Content[key]
+		}
+		version, notes, err := upgrade(root, kind, provisioner)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		// the files without changes to convert are read as they are, warning about every run would be noise
+		if version != APIVersion && len(notes) > 0 {
+			notes = append([]string{fmt.Sprintf("deprecated apiVersion %s, run furyctl config migrate to update it to %s", version, APIVersion)}, notes...)
+			for _, note := range notes {
+				opts.warn(ValidationError{File: path, Path: "apiVersion", Line: position.Line, Column: position.Column, Message: note})
+			}
+		}
+		indexFiles(root, path, d.files)
+		if i > 0 {
+			mergeNodes(d.root, root)
+		}
+	}
+
+	// unknown kinds and provisioners are reported by the parsers
+	if schema, err := Schema(kind, provisioner); resolve && err == nil {
+		// the unknown fields have been reported checking every file
+		if errs, _ := schema.validateDocument(d.root, false).unknownFields(); len(errs) > 0 {
+			d.locate(errs)
+			return nil, errs
+		}
+	}
+	return d, nil
+}
+
+// locate sets the file, line and column of the errors found in the merged document
+func (d *document) locate(errs ValidationErrors) {
+	errs.locate(d.root, d.files, d.file)
+}
+
+func indexFiles(node *yamlv3.Node, file string, files map[*yamlv3.Node]string) {
+	files[node] = file
+	for _, child := range node.Content {
+		indexFiles(child, file, files)
+	}
+}
+
+// mergeNodes merges an overlay into a base yaml object in place.
+// Maps are merged key by key, lists of objects with a name are merged by name, a null removes the value
+// and any other value replaces the base one
+func mergeNodes(base, overlay *yamlv3.Node) {
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		j := keyIndex(base, key.Value)
+		switch {
+		case j < 0 && value.Tag == "!!null":
+		case j < 0:
+			base.Content = append(base.Content, key, value)
+		case value.Tag == "!!null":
+			base.Content = append(base.Content[:j], base.Content[j+2:]...)
+		default:
+			base.Content[j+1] = mergeValue(base.Content[j+1], value)
+		}
+	}
+}
+
+func mergeValue(base, overlay *yamlv3.Node) *yamlv3.Node {
+	switch {
+	case base.Kind == yamlv3.MappingNode && overlay.Kind == yamlv3.MappingNode:
+		mergeNodes(base, overlay)
+		return base
+	case base.Kind == yamlv3.SequenceNode && overlay.Kind == yamlv3.SequenceNode && len(overlay.Content) > 0 && named(base) && named(overlay):
+		for _, item := range overlay.Content {
+			if existing := findNamed(base, scalarValue(item, "name")); existing != nil {
+				mergeNodes(existing, item)
+			} else {
+				base.Content = append(base.Content, item)
+			}
+		}
+		return base
+	default:
+		return overlay
+	}
+}
+
+// named tells whether all the items of a list are objects with a name
+func named(list *yamlv3.Node) bool {
+	for _, item := range list.Content {
+		if scalarValue(item, "name") == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func findNamed(list *yamlv3.Node, name string) *yamlv3.Node {
+	for _, item := range list.Content {
+		if scalarValue(item, "name") == name {
+			return item
+		}
+	}
+	return nil
+}
+
+func keyIndex(node *yamlv3.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// View returns the effective configuration of a file, read from a reader named source, merged with its overlays and
+// converted to the latest version. When resolve is set the references of the values are resolved too
+func View(r io.Reader, source string, opts Options, resolve bool) ([]byte, error) {
+	d, err := load(r, source, opts, resolve)
+	if err != nil {
+		return nil, err
+	}
+	return encodeDocument(d.node)
+}
+
+// ViewDefaulted returns the effective configuration as View does, with the default of every missing field.
+// The defaulted values are marked with a # default comment
+func ViewDefaulted(r io.Reader, source string, opts Options, resolve bool) ([]byte, error) {
+	d, err := load(r, source, opts, resolve)
+	if err != nil {
+		return nil, err
+	}
+	applyDefaults(d.root, true)
+	return encodeDocument(d.node)
+}
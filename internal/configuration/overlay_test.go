@@ -0,0 +1,233 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+const baseCluster = `apiVersion: furyctl.sighup.io/v1alpha2
+kind: Cluster
+metadata:
+  name: demo
+  labels:
+    team: platform
+provisioner: eks
+spec:
+  version: "1.18"
+  network: vpc-dev
+  subnetworks:
+    - subnet-1
+    - subnet-2
+  dmzCIDRRange: 10.0.0.0/16
+  sshPublicKey: ssh-rsa dev
+  nodePools:
+    - name: infra
+      minSize: 1
+      maxSize: 3
+      instanceType: t3.large
+      labels:
+        role: infra
+    - name: app
+      minSize: 1
+      maxSize: 2
+      instanceType: t3.medium
+  tags:
+    env: dev
+`
+
+const prodOverlay = `# production
+metadata:
+  labels:
+    env: production
+spec:
+  network: vpc-prod
+  subnetworks:
+    - subnet-3
+  sshPublicKey: ~
+  nodePools:
+    - name: app
+      maxSize: 10
+      instanceType: m5.xlarge
+    - name: gpu
+      minSize: 0
+      maxSize: 2
+      instanceType: p3.2xlarge
+`
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "furyctl-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// readFile returns the content of a configuration file and its path, the source of Parse
+func readFile(t *testing.T, path string) (io.Reader, string) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.NewReader(content), path
+}
+
+func TestParseOverlays(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"base.yml": baseCluster, "prod.yml": prodOverlay})
+	defer os.RemoveAll(dir)
+
+	r, base := readFile(t, filepath.Join(dir, "base.yml"))
+	config, err := Parse(r, base, filepath.Join(dir, "prod.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLabels := map[string]interface{}{"team": "platform", "env": "production"}
+	if config.Metadata.Name != "demo" || !reflect.DeepEqual(config.Metadata.Labels, wantLabels) {
+		t.Errorf("Parse() metadata = %+v", config.Metadata)
+	}
+	spec := config.Spec.(clustercfg.EKS)
+	if spec.Network != "vpc-prod" || !reflect.DeepEqual(spec.SubNetworks, []string{"subnet-3"}) || spec.SSHPublicKey != "" {
+		t.Errorf("Parse() spec = %+v", spec)
+	}
+	var pools []string
+	for _, pool := range spec.NodePools {
+		pools = append(pools, pool.Name+" "+pool.InstanceType)
+	}
+	if want := []string{"infra t3.large", "app m5.xlarge", "gpu p3.2xlarge"}; !reflect.DeepEqual(pools, want) {
+		t.Errorf("Parse() nodePools = %v, want %v", pools, want)
+	}
+	if spec.NodePools[1].MinSize != 1 || spec.NodePools[1].MaxSize != 10 {
+		t.Errorf("Parse() app nodePool = %+v", spec.NodePools[1])
+	}
+}
+
+func TestParseOverlayErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yml":    baseCluster,
+		"invalid.yml": "spec:\n  nodePools:\n    - name: app\n      maxSize: ten\n",
+		"network.yml": "spec:\n  dmzCIDRRange: 10.0.0.0\n",
+	})
+	defer os.RemoveAll(dir)
+
+	for overlay, want := range map[string]ValidationError{
+		"invalid.yml": {File: filepath.Join(dir, "invalid.yml"), Path: "spec.nodePools[0].maxSize", Line: 4, Column: 16, Message: `expected an integer, got "ten"`},
+		"network.yml": {File: filepath.Join(dir, "network.yml"), Path: "spec.dmzCIDRRange[0]", Line: 2, Column: 17, Message: `"10.0.0.0" is not a valid CIDR`},
+	} {
+		r, base := readFile(t, filepath.Join(dir, "base.yml"))
+		_, err := Parse(r, base, filepath.Join(dir, overlay))
+		errs, ok := err.(ValidationErrors)
+		if !ok || len(errs) != 1 || errs[0] != want {
+			t.Errorf("Parse() with %s error = %#v, want %#v", overlay, err, want)
+		}
+	}
+}
+
+func TestView(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"base.yml": "kind: Cluster\nmetadata:\n  name: ${NAME}\nprovisioner: eks\nexecutor:\n  version: 0.12.29\n",
+		"prod.yml": "metadata:\n  name: ${NAME}-prod # production\n",
+	})
+	defer os.RemoveAll(dir)
+
+	r, base := readFile(t, filepath.Join(dir, "base.yml"))
+	view, err := View(r, base, Options{Overlays: []string{filepath.Join(dir, "prod.yml")}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: furyctl.sighup.io/v1alpha2
+kind: Cluster
+metadata:
+  name: ${NAME}-prod # production
+provisioner: eks
+`
+	if string(view) != want {
+		t.Errorf("View() =\n%s\nwant\n%s", view, want)
+	}
+}
+
+func TestViewDefaulted(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"cluster.yml": `apiVersion: furyctl.sighup.io/v1alpha2
+kind: Cluster
+metadata:
+  name: demo
+provisioner: gke
+spec:
+  version: "1.21"
+  network: network-1
+  subnetworks:
+    - subnet-1
+  dmzCIDRRange: 0.0.0.0/0
+  sshPublicKey: ssh-rsa demo
+  controlPlaneCIDR: 10.1.0.0/28
+  nodePools:
+    - name: pool
+      maxSize: 3
+      instanceType: n1-standard-2
+      volumeSize: 50
+      spotInstance: true
+    - name: spot
+      maxSize: 3
+      instanceType: n1-standard-2
+      volumeSize: 50
+`,
+	})
+	defer os.RemoveAll(dir)
+
+	r, source := readFile(t, filepath.Join(dir, "cluster.yml"))
+	view, err := ViewDefaulted(r, source, Options{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `apiVersion: furyctl.sighup.io/v1alpha2
+kind: Cluster
+metadata:
+  name: demo
+provisioner: gke
+spec:
+  version: "1.21"
+  network: network-1
+  subnetworks:
+    - subnet-1
+  dmzCIDRRange: 0.0.0.0/0
+  sshPublicKey: ssh-rsa demo
+  controlPlaneCIDR: 10.1.0.0/28
+  nodePools:
+    - name: pool
+      maxSize: 3
+      instanceType: n1-standard-2
+      volumeSize: 50
+      spotInstance: true
+    - name: spot
+      maxSize: 3
+      instanceType: n1-standard-2
+      volumeSize: 50
+      spotInstance: false # default
+  additionalFirewallRules: true # default
+  additionalClusterFirewallRules: false # default
+  disableDefaultSNAT: false # default
+executor:
+  state:
+    backend: local # default
+hooks:
+  timeout: 5m # default
+`
+	if string(view) != want {
+		t.Errorf("ViewDefaulted() =\n%s\nwant\n%s", view, want)
+	}
+}
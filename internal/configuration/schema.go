@@ -0,0 +1,186 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	bootstrapcfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+const jsonSchemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// specs contains the spec type of every kind and provisioner
+var specs = map[string]map[string]interface{}{
+	"Cluster": {
+		"eks":     clustercfg.EKS{},
+		"gke":     clustercfg.GKE{},
+		"vsphere": clustercfg.VSphere{},
+	},
+	"Bootstrap": {
+		"aws": bootstrapcfg.AWS{},
+		"gcp": bootstrapcfg.GCP{},
+	},
+}
+
+// customSchemas contains the schema of the types with a custom yaml representation
+var customSchemas = map[reflect.Type]*JSONSchema{
+	reflect.TypeOf(clustercfg.DMZCIDRRange{}): {
+		OneOf: []*JSONSchema{
+			{Type: "string"},
+			{Type: "array", Items: &JSONSchema{Type: "string"}},
+		},
+	},
+}
+
+// JSONSchema is the subset of the JSON Schema draft 7 used to describe the configuration files
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Const                string                 `json:"const,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Default              interface{}            `json:"default,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	// closed rejects the properties missing from Properties, it is the additionalProperties: false of the structs
+	closed bool
+}
+
+// MarshalJSON writes the closed schemas with additionalProperties: false
+func (s JSONSchema) MarshalJSON() ([]byte, error) {
+	type schema JSONSchema
+	if !s.closed {
+		return json.Marshal(schema(s))
+	}
+	return json.Marshal(struct {
+		schema
+		AdditionalProperties bool `json:"additionalProperties"`
+	}{schema(s), false})
+}
+
+// Kinds returns the supported configuration kinds
+func Kinds() []string {
+	kinds := make([]string, 0, len(specs))
+	for kind := range specs {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Provisioners returns the provisioners supported by a configuration kind
+func Provisioners(kind string) []string {
+	provisioners := make([]string, 0, len(specs[kind]))
+	for provisioner := range specs[kind] {
+		provisioners = append(provisioners, provisioner)
+	}
+	sort.Strings(provisioners)
+	return provisioners
+}
+
+// Schema returns the JSON Schema of the latest version of the configuration file of a kind and provisioner
+func Schema(kind string, provisioner string) (*JSONSchema, error) {
+	return VersionSchema(APIVersion, kind, provisioner)
+}
+
+// VersionSchema returns the JSON Schema of a version of the configuration file of a kind and provisioner
+func VersionSchema(version string, kind string, provisioner string) (*JSONSchema, error) {
+	i, err := lookupAPIVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	v := apiVersions[i]
+	provisioners, ok := v.specs[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown kind %s. Choose one of: %s", kind, strings.Join(Kinds(), ", "))
+	}
+	spec, ok := provisioners[provisioner]
+	if !ok {
+		return nil, fmt.Errorf("unknown %s provisioner %s. Choose one of: %s", kind, provisioner, strings.Join(Provisioners(kind), ", "))
+	}
+
+	schema := schemaOf(reflect.TypeOf(v.base))
+	schema.Schema = jsonSchemaDraft
+	schema.Title = fmt.Sprintf("furyctl %s %s configuration for the %s provisioner", v.name, kind, provisioner)
+	schema.Required = []string{"kind", "metadata", "provisioner", "spec"}
+	schema.Properties["apiVersion"].Const = v.name
+	schema.Properties["kind"].Const = kind
+	schema.Properties["provisioner"].Const = provisioner
+	schema.Properties["metadata"].Required = []string{"name"}
+	schema.Properties["spec"] = describeSchema(schemaOf(reflect.TypeOf(spec)), lookupField(reflect.TypeOf(Configuration{}), "spec"))
+	return schema, nil
+}
+
+// schemaOf generates the schema of a type from its yaml representation
+func schemaOf(t reflect.Type) *JSONSchema {
+	if s, ok := customSchemas[t]; ok {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem())
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &JSONSchema{Type: "array", Items: schemaOf(t.Elem())}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: schemaOf(t.Elem())}
+	case reflect.Struct:
+		s := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}, closed: true}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name := yamlName(f)
+			if name == "" {
+				continue
+			}
+			s.Properties[name] = describeSchema(schemaOf(f.Type), lookupField(t, name))
+		}
+		return s
+	default:
+		// interface{} accepts any value
+		return &JSONSchema{}
+	}
+}
+
+// describeSchema returns a copy of the schema of a field with its documentation, the schemas of the types are shared
+func describeSchema(s *JSONSchema, f field) *JSONSchema {
+	described := *s
+	described.Description = f.description
+	described.Enum = f.enum
+	described.Default = f.defaultValue
+	return &described
+}
+
+// yamlName returns the key of a struct field in the yaml files, empty for the ignored fields
+func yamlName(f reflect.StructField) string {
+	if f.PkgPath != "" {
+		return ""
+	}
+	name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return strings.ToLower(f.Name)
+	}
+	return name
+}
@@ -0,0 +1,250 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+func TestTemplatesMatchSchema(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, kind := range Kinds() {
+		for _, provisioner := range Provisioners(kind) {
+			schema, err := Schema(kind, provisioner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = json.Marshal(schema); err != nil {
+				t.Fatal(err)
+			}
+			for _, minimal := range []bool{false, true} {
+				tpl, err := TemplateWithOptions(kind, provisioner, TemplateOptions{Minimal: minimal})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = schema.Validate([]byte(tpl), ""); err != nil {
+					t.Errorf("%s %s template (minimal %v) does not match the schema: %v", kind, provisioner, minimal, err)
+				}
+				path := filepath.Join(dir, fmt.Sprintf("%s-%s-%v.yml", kind, provisioner, minimal))
+				if err = ioutil.WriteFile(path, []byte(tpl), 0644); err != nil {
+					t.Fatal(err)
+				}
+				if _, err = Parse(readFile(t, path)); err != nil {
+					t.Errorf("%s %s template (minimal %v) is not valid: %v", kind, provisioner, minimal, err)
+				}
+			}
+		}
+	}
+}
+
+func TestMinimalTemplate(t *testing.T) {
+	tpl, err := TemplateWithOptions("Bootstrap", "aws", TemplateOptions{Minimal: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# furyctl Bootstrap configuration for the aws provisioner.
+# Print its JSON Schema with: furyctl config schema --kind Bootstrap --provisioner aws
+
+# Version of the configuration file, the files without it are read as furyctl.sighup.io/v1alpha1. Optional.
+apiVersion: furyctl.sighup.io/v1alpha2
+# Kind of the configuration file. Required.
+kind: Bootstrap
+# Metadata of the resources. Required.
+metadata:
+  # Name of the project, used to identify its resources. Required.
+  name: my-project
+# Specification of the resources created by the provisioner. Required.
+spec:
+  # VPC network CIDR. Required.
+  networkCIDR: 10.0.0.0/16
+  # Public subnet CIDRs, inside the networkCIDR. Required.
+  publicSubnetsCIDRs:
+    - 10.0.1.0/24
+    - 10.0.2.0/24
+    - 10.0.3.0/24
+  # Private subnet CIDRs, inside the networkCIDR. Required.
+  privateSubnetsCIDRs:
+    - 10.0.101.0/24
+    - 10.0.102.0/24
+    - 10.0.103.0/24
+  # VPN servers giving access to the private subnets. Required.
+  vpn:
+    # CIDR of the VPN clients, disjoint from the network CIDRs. Required.
+    subnetCIDR: 192.168.200.0/24
+    # GitHub users whose public keys are authorized to log into the VPN servers. Required.
+    sshUsers:
+      - github-user
+# Provisioner creating the resources. Required.
+provisioner: aws
+`
+	if tpl != want {
+		t.Errorf("minimal template =\n%s\nwant\n%s", tpl, want)
+	}
+
+	full, err := Template("Bootstrap", "aws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"    # Port of the VPN servers. Optional, defaults to 1194.\n    port: 1194\n",
+		`    # CIDRs allowed to log into the VPN servers via SSH. Optional, defaults to ["0.0.0.0/0"].` + "\n",
+	} {
+		if !strings.Contains(full, line) {
+			t.Errorf("full template does not contain %q:\n%s", line, full)
+		}
+	}
+}
+
+func TestValidateEnum(t *testing.T) {
+	content := `kind: Cluster
+metadata:
+  name: demo
+provisioner: gke
+spec:
+  nodePools:
+    - name: one
+      additionalFirewallRules:
+        - name: dns
+          direction: inbound
+`
+	schema, err := Schema("Cluster", "gke")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = schema.Validate([]byte(content), "cluster.yml")
+	want := "invalid configuration:\ncluster.yml:10:22: spec.nodePools[0].additionalFirewallRules[0].direction: expected one of ingress, egress, got inbound"
+	if err == nil || err.Error() != want {
+		t.Errorf("Validate() error = %v, want %s", err, want)
+	}
+}
+
+func TestSchemaUnknownProvisioner(t *testing.T) {
+	if _, err := Schema("Cluster", "aws"); err == nil {
+		t.Error("Schema() of a bootstrap provisioner for a cluster should fail")
+	}
+	if _, err := Schema("Network", "aws"); err == nil {
+		t.Error("Schema() of an unknown kind should fail")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	content := `kind: Cluster
+metadata:
+  labels:
+    env: prod
+provisioner: eks
+spec:
+  version: 1.18
+  dmzCIDRRange:
+    cidr: 0.0.0.0/0
+  nodePools:
+    - name: one
+      maxPods: many
+      spotInstance: yes
+      labels: [a, b]
+`
+	schema, err := Schema("Cluster", "eks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = schema.Validate([]byte(content), "cluster.yml")
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Validate() error = %v, want ValidationErrors", err)
+	}
+	want := ValidationErrors{
+		{File: "cluster.yml", Path: "metadata", Line: 3, Column: 3, Message: "missing required field name"},
+		{File: "cluster.yml", Path: "spec.dmzCIDRRange", Line: 9, Column: 5, Message: "expected a string or an array, got an object"},
+		{File: "cluster.yml", Path: "spec.nodePools[0].maxPods", Line: 12, Column: 16, Message: `expected an integer, got "many"`},
+		{File: "cluster.yml", Path: "spec.nodePools[0].labels", Line: 14, Column: 15, Message: "expected an object, got an array"},
+	}
+	if !reflect.DeepEqual(errs, want) {
+		t.Errorf("Validate() errors =\n%v\nwant\n%v", errs, want)
+	}
+	if errs[2].Error() != `cluster.yml:12:16: spec.nodePools[0].maxPods: expected an integer, got "many"` {
+		t.Errorf("unexpected error message %s", errs[2].Error())
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"cluster.yml": `apiVersion: furyctl.sighup.io/v1alpha2
+kind: Cluster
+metadata:
+  name: demo
+provisioner: eks
+executor:
+  version: 0.12.29
+spec:
+  version: "1.18"
+  nodepools:
+    - name: one
+  tags:
+    anything: goes
+  sshPubKey: ssh-rsa
+  flavour: vanilla
+`})
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cluster.yml")
+
+	_, err := Parse(readFile(t, path))
+	errs, ok := err.(ValidationErrors)
+	if !ok {
+		t.Fatalf("Parse() error = %v, want ValidationErrors", err)
+	}
+	var got []string
+	for _, e := range errs {
+		got = append(got, fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message))
+	}
+	want := []string{
+		"7:3: executor.version: unknown field version, removed in furyctl.sighup.io/v1alpha2, furyctl always uses its own terraform binary",
+		"10:3: spec.nodepools: unknown field nodepools, did you mean nodePools?",
+		"14:3: spec.sshPubKey: unknown field sshPubKey, did you mean sshPublicKey?",
+		"15:3: spec.flavour: unknown field flavour",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() errors =\n%v\nwant\n%v", got, want)
+	}
+
+	r, _ := readFile(t, path)
+	config, err := ParseWithOptions(r, path, Options{AllowUnknownFields: true})
+	if err != nil {
+		t.Fatalf("ParseWithOptions() allowing unknown fields error = %v", err)
+	}
+	if config.Spec.(clustercfg.EKS).Version != "1.18" {
+		t.Errorf("ParseWithOptions() = %+v", config)
+	}
+}
+
+func TestClosedSchema(t *testing.T) {
+	schema, err := Schema("Cluster", "eks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	content, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(content, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	labels := decoded["properties"].(map[string]interface{})["metadata"].(map[string]interface{})["properties"].(map[string]interface{})["labels"].(map[string]interface{})
+	if decoded["additionalProperties"] != false || labels["additionalProperties"] == false {
+		t.Errorf("only the structs should reject the additional properties: %s", content)
+	}
+}
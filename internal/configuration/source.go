@@ -0,0 +1,174 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	getter "github.com/hashicorp/go-getter"
+)
+
+const (
+	// Stdin is the location of the configuration file read from the standard input
+	Stdin = "-"
+	// stdinName is the name of the standard input in the messages
+	stdinName = "<stdin>"
+)
+
+// stdin is replaced by the tests
+var stdin io.Reader = os.Stdin
+
+// remotePattern matches the go-getter URLs: a forced getter as git:: or a scheme as https://
+var remotePattern = regexp.MustCompile(`^([a-z0-9]+::|[a-z][a-z0-9+.-]*://)`)
+
+// forcedGetterPattern matches the forced getter of the go-getter URLs, as git::
+var forcedGetterPattern = regexp.MustCompile(`^[a-z0-9]+::`)
+
+// secretParams are the query parameters of the go-getter URLs carrying credentials, compared in lowercase
+var secretParams = map[string]bool{
+	"aws_access_key_id":     true,
+	"aws_access_key_secret": true,
+	"aws_access_token":      true,
+	"x-amz-credential":      true,
+	"x-amz-security-token":  true,
+	"x-amz-signature":       true,
+	"access_token":          true,
+	"token":                 true,
+	"password":              true,
+	"sshkey":                true,
+	"sig":                   true,
+}
+
+// Open opens the configuration file of a location, returning its name in the messages.
+// The location is a path, - for the standard input or a go-getter URL as git::https://github.com/org/repo//cluster.yml?ref=v1.0.0,
+// https://example.com/cluster.yml or s3::https://s3.amazonaws.com/bucket/cluster.yml.
+// The checksum parameter pins the content of the URLs, as ?checksum=sha256:<hex digest>.
+// The name of the URLs has no credentials: the userinfo and the secret query parameters are removed
+func Open(location string) (io.ReadCloser, string, error) {
+	switch {
+	case location == Stdin:
+		return ioutil.NopCloser(stdin), stdinName, nil
+	case remotePattern.MatchString(location):
+		name := redactLocation(location)
+		content, err := download(location)
+		if err != nil {
+			return nil, "", redactError(location, err)
+		}
+		return ioutil.NopCloser(bytes.NewReader(content)), name, nil
+	default:
+		f, err := os.Open(location)
+		if err != nil {
+			return nil, "", err
+		}
+		return f, location, nil
+	}
+}
+
+// download fetches a remote configuration file, verifying its checksum when the URL has one
+func download(location string) ([]byte, error) {
+	dir, err := ioutil.TempDir("", "furyctl-config")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	pwd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	dst := filepath.Join(dir, "config.yml")
+	client := &getter.Client{
+		Src:  location,
+		Dst:  dst,
+		Pwd:  pwd,
+		Mode: getter.ClientModeFile,
+	}
+	if err = client.Get(); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(dst)
+}
+
+// redactLocation removes the credentials from a go-getter URL, the locations that are not valid URLs are returned as they are
+func redactLocation(location string) string {
+	u, forced, secrets := parseSecrets(location)
+	if u == nil || u.User == nil && len(secrets) == 0 {
+		return location
+	}
+	u.User = nil
+	query := u.Query()
+	for param := range query {
+		if secretParams[strings.ToLower(param)] {
+			query.Del(param)
+		}
+	}
+	u.RawQuery = query.Encode()
+	return forced + u.String()
+}
+
+// redactError replaces the credentials of a go-getter URL in the error of its download, which can include the URL
+func redactError(location string, err error) error {
+	msg := err.Error()
+	_, _, secrets := parseSecrets(location)
+	for _, secret := range secrets {
+		if secret != "" {
+			msg = strings.Replace(msg, secret, "***", -1)
+		}
+	}
+	return fmt.Errorf("%s: %s", redactLocation(location), msg)
+}
+
+// parseSecrets parses a go-getter URL, returning its forced getter and the values of its credentials
+func parseSecrets(location string) (u *url.URL, forced string, secrets []string) {
+	forced = forcedGetterPattern.FindString(location)
+	u, err := url.Parse(strings.TrimPrefix(location, forced))
+	if err != nil {
+		return nil, forced, nil
+	}
+	if u.User != nil {
+		// the https URLs can carry a token as the user name, the ssh ones the plain git user
+		if password, ok := u.User.Password(); ok {
+			secrets = append(secrets, password)
+		} else if strings.HasPrefix(u.Scheme, "http") {
+			secrets = append(secrets, u.User.Username())
+		}
+	}
+	for param, values := range u.Query() {
+		if secretParams[strings.ToLower(param)] {
+			secrets = append(secrets, values...)
+		}
+	}
+	return u, forced, secrets
+}
+
+// readLocation reads the configuration file of a location, returning its name in the messages
+func readLocation(location string) ([]byte, string, error) {
+	f, name, err := Open(location)
+	if err != nil {
+		return nil, "", err
+	}
+	defer f.Close()
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %v", name, err)
+	}
+	return content, name, nil
+}
+
+// sourceDir returns the directory the relative file references of a configuration file are resolved from:
+// the directory of a local file, the working directory for the standard input and the URLs
+func sourceDir(name string) string {
+	if name == stdinName || remotePattern.MatchString(name) {
+		return "."
+	}
+	return filepath.Dir(name)
+}
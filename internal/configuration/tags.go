@@ -0,0 +1,192 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	bootstrapcfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+// tagConstraints are the constraints of the tags of a cloud. The invalid characters are replaced by underscores
+type tagConstraints struct {
+	name string
+	// lowercase converts the keys and the values to lowercase
+	lowercase bool
+	// valid reports whether a character is accepted in the keys and the values
+	valid func(r rune) bool
+	// keyLength and valueLength truncate the keys and the values, 0 keeps them whole
+	keyLength   int
+	valueLength int
+	// reserved reports why a normalized key cannot be used, empty when it is valid. It can be nil
+	reserved func(key string) string
+}
+
+// awsTags are the constraints of the AWS tags
+var awsTags = &tagConstraints{
+	name: "AWS tags",
+	valid: func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune(" _.:/=+-@", r)
+	},
+	keyLength:   128,
+	valueLength: 256,
+	reserved: func(key string) string {
+		if strings.HasPrefix(strings.ToLower(key), "aws:") {
+			return "the aws: prefix is reserved by AWS"
+		}
+		return ""
+	},
+}
+
+// gcpLabels are the constraints of the GCP labels
+var gcpLabels = &tagConstraints{
+	name:      "GCP labels",
+	lowercase: true,
+	valid: func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' || r == '-'
+	},
+	keyLength:   63,
+	valueLength: 63,
+	reserved: func(key string) string {
+		if key[0] < 'a' || key[0] > 'z' {
+			return "the GCP label keys must start with a letter"
+		}
+		return ""
+	},
+}
+
+// vsphereAttributes are the constraints of the vSphere custom attributes, set on the virtual machines
+var vsphereAttributes = &tagConstraints{
+	name:  "vSphere custom attributes",
+	valid: unicode.IsPrint,
+}
+
+// tagClouds contains the constraints of the tags of every provisioner supporting them
+var tagClouds = map[string]*tagConstraints{
+	"aws": awsTags,
+	"eks": awsTags,
+	"gcp": gcpLabels,
+	"gke": gcpLabels,
+
+	"vsphere": vsphereAttributes,
+}
+
+func (c *tagConstraints) normalize(s string, length int) string {
+	if c.lowercase {
+		s = strings.ToLower(s)
+	}
+	s = strings.Map(func(r rune) rune {
+		if c.valid(r) {
+			return r
+		}
+		return '_'
+	}, s)
+	if length > 0 && len(s) > length {
+		s = s[:length]
+	}
+	return s
+}
+
+// reason returns why a normalized key cannot be used, empty when it is valid
+func (c *tagConstraints) reason(key string) string {
+	if c.reserved == nil {
+		return ""
+	}
+	return c.reserved(key)
+}
+
+// ResourceTags returns the tags of the cloud resources created by a provisioner: the metadata labels merged with
+// the tags of the spec, which take precedence, normalized to the constraints of the cloud.
+// The configurations returned by Parse are already checked, the tags failing the checks are skipped
+func ResourceTags(config *Configuration) map[string]string {
+	tags, _, _ := resourceTags(config)
+	return tags
+}
+
+// validateTags checks the metadata labels and the tags of the spec can be normalized to the tags of the cloud
+func validateTags(config *Configuration) (errs ValidationErrors, warnings ValidationErrors) {
+	_, errs, warnings = resourceTags(config)
+	return errs, warnings
+}
+
+func resourceTags(config *Configuration) (tags map[string]string, errs ValidationErrors, warnings ValidationErrors) {
+	var specTags map[string]string
+	switch s := config.Spec.(type) {
+	case bootstrapcfg.AWS:
+		specTags = s.Tags
+	case bootstrapcfg.GCP:
+		specTags = s.Tags
+	case clustercfg.EKS:
+		specTags = s.Tags
+	case clustercfg.GKE:
+		specTags = s.Tags
+	}
+	labels := map[string]string{}
+	for _, key := range sortedKeys(config.Metadata.Labels) {
+		switch value := config.Metadata.Labels[key].(type) {
+		case nil:
+			labels[key] = ""
+		case map[interface{}]interface{}, []interface{}:
+			errs = append(errs, ValidationError{Path: "metadata.labels." + key, Message: "expected a string, a number or a boolean label value"})
+		default:
+			labels[key] = fmt.Sprint(value)
+		}
+	}
+
+	c, ok := tagClouds[config.Provisioner]
+	if !ok {
+		return nil, errs, warnings
+	}
+
+	tags = map[string]string{}
+	// the labels are merged first, the tags of the spec replace them
+	for _, source := range []struct {
+		path   string
+		values map[string]string
+	}{{"metadata.labels", labels}, {"spec.tags", specTags}} {
+		sources := map[string]string{}
+		keys := make([]string, 0, len(source.values))
+		for key := range source.values {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			path := source.path + "." + key
+			normalizedKey := c.normalize(key, c.keyLength)
+			if normalizedKey == "" {
+				errs = append(errs, ValidationError{Path: path, Message: "the tag keys cannot be empty"})
+				continue
+			}
+			if reason := c.reason(normalizedKey); reason != "" {
+				errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("invalid key %s, %s", key, reason)})
+				continue
+			}
+			if other, ok := sources[normalizedKey]; ok {
+				errs = append(errs, ValidationError{Path: path, Message: fmt.Sprintf("%s and %s are both normalized to the %s key %s", other, key, c.name, normalizedKey)})
+				continue
+			}
+			sources[normalizedKey] = key
+			value := c.normalize(source.values[key], c.valueLength)
+			if normalizedKey != key || value != source.values[key] {
+				warnings = append(warnings, ValidationError{Path: path, Message: fmt.Sprintf("normalized to %s: %s to match the constraints of the %s", normalizedKey, value, c.name)})
+			}
+			tags[normalizedKey] = value
+		}
+	}
+	return tags, errs, warnings
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"reflect"
+	"testing"
+
+	bootstrapcfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+func TestResourceTags(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Configuration
+		wantTags     map[string]string
+		wantErrs     ValidationErrors
+		wantWarnings ValidationErrors
+	}{
+		{
+			name: "the spec tags replace the labels",
+			config: Configuration{
+				Provisioner: "eks",
+				Metadata:    Metadata{Labels: map[string]interface{}{"team": "platform", "environment": "staging", "replicas": 3}},
+				Spec:        clustercfg.EKS{Tags: map[string]string{"environment": "production"}},
+			},
+			wantTags: map[string]string{"team": "platform", "environment": "production", "replicas": "3"},
+		},
+		{
+			name: "the AWS tags replace the invalid characters",
+			config: Configuration{
+				Provisioner: "aws",
+				Metadata:    Metadata{Labels: map[string]interface{}{"owner": "Jane <jane@example.com>", "aws:team": "platform"}},
+				Spec:        bootstrapcfg.AWS{},
+			},
+			wantTags: map[string]string{"owner": "Jane _jane@example.com_"},
+			wantErrs: ValidationErrors{
+				{Path: "metadata.labels.aws:team", Message: "invalid key aws:team, the aws: prefix is reserved by AWS"},
+			},
+			wantWarnings: ValidationErrors{
+				{Path: "metadata.labels.owner", Message: "normalized to owner: Jane _jane@example.com_ to match the constraints of the AWS tags"},
+			},
+		},
+		{
+			name: "the GCP labels are lowercase",
+			config: Configuration{
+				Provisioner: "gke",
+				Metadata: Metadata{Labels: map[string]interface{}{
+					"Team":    "Platform",
+					"team":    "core",
+					"1st":     "yes",
+					"release": map[interface{}]interface{}{"name": "v1"},
+				}},
+				Spec: clustercfg.GKE{Tags: map[string]string{"app.kubernetes.io/name": "demo"}},
+			},
+			wantTags: map[string]string{"team": "platform", "app_kubernetes_io_name": "demo"},
+			wantErrs: ValidationErrors{
+				{Path: "metadata.labels.release", Message: "expected a string, a number or a boolean label value"},
+				{Path: "metadata.labels.1st", Message: "invalid key 1st, the GCP label keys must start with a letter"},
+				{Path: "metadata.labels.team", Message: "Team and team are both normalized to the GCP labels key team"},
+			},
+			wantWarnings: ValidationErrors{
+				{Path: "metadata.labels.Team", Message: "normalized to team: platform to match the constraints of the GCP labels"},
+				{Path: "spec.tags.app.kubernetes.io/name", Message: "normalized to app_kubernetes_io_name: demo to match the constraints of the GCP labels"},
+			},
+		},
+		{
+			name: "the vSphere custom attributes keep the printable characters",
+			config: Configuration{
+				Provisioner: "vsphere",
+				Metadata:    Metadata{Labels: map[string]interface{}{"Team": "Platform", "owner": "Jane <jane@example.com>", "notes": "line\nbreak"}},
+				Spec:        clustercfg.VSphere{},
+			},
+			wantTags: map[string]string{"Team": "Platform", "owner": "Jane <jane@example.com>", "notes": "line_break"},
+			wantWarnings: ValidationErrors{
+				{Path: "metadata.labels.notes", Message: "normalized to notes: line_break to match the constraints of the vSphere custom attributes"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, errs, warnings := resourceTags(&tt.config)
+			if !reflect.DeepEqual(tags, tt.wantTags) {
+				t.Errorf("resourceTags() tags = %v, want %v", tags, tt.wantTags)
+			}
+			if !reflect.DeepEqual(errs, tt.wantErrs) {
+				t.Errorf("resourceTags() errs = %v, want %v", errs, tt.wantErrs)
+			}
+			if !reflect.DeepEqual(warnings, tt.wantWarnings) {
+				t.Errorf("resourceTags() warnings = %v, want %v", warnings, tt.wantWarnings)
+			}
+		})
+	}
+}
@@ -6,321 +6,142 @@
 package configuration
 
 import (
+	"encoding/json"
 	"fmt"
+	"reflect"
+	"strings"
 
-	bootstrapcfg "github.com/sighupio/furyctl/internal/bootstrap/configuration"
-	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
-
-	log "github.com/sirupsen/logrus"
-	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
 )
 
-// Template generates a yaml with a sample configuration requested by the client
+// TemplateOptions configures the generated templates
+type TemplateOptions struct {
+	// Minimal writes only the required fields
+	Minimal bool
+}
+
+// Template generates a yaml with a sample configuration requested by the client, listing every field
 func Template(kind string, provisioner string) (string, error) {
-	config := Configuration{}
-	config.Kind = kind
-	config.Provisioner = provisioner
-	switch {
-	case kind == "Bootstrap":
-		err := bootstrapTemplate(&config)
-		if err != nil {
-			return "", err
-		}
-	case kind == "Cluster":
-		err := clusterTemplate(&config)
-		if err != nil {
-			return "", err
-		}
-	default:
-		log.Errorf("Error creating a template configuration file. Parser not found for %v kind", kind)
-		return "", fmt.Errorf("error creating a template configuration file. Parser not found for %v kind", kind)
+	return TemplateWithOptions(kind, provisioner, TemplateOptions{})
+}
+
+// TemplateWithOptions generates a yaml with a sample configuration of a kind and provisioner.
+// Every field is commented with its description, whether it is required, its default and its accepted values
+func TemplateWithOptions(kind string, provisioner string, opts TemplateOptions) (string, error) {
+	if _, err := Schema(kind, provisioner); err != nil {
+		return "", err
+	}
+	g := &templater{
+		minimal: opts.Minimal,
+		spec:    reflect.TypeOf(specs[kind][provisioner]),
+		values:  map[string]string{"apiVersion": APIVersion, "kind": kind, "provisioner": provisioner},
 	}
-	b, err := yaml.Marshal(config)
+	root, err := g.object(reflect.TypeOf(Configuration{}))
+	if err != nil {
+		return "", err
+	}
+	doc := &yamlv3.Node{
+		Kind:        yamlv3.DocumentNode,
+		HeadComment: fmt.Sprintf("furyctl %s configuration for the %s provisioner.\nPrint its JSON Schema with: furyctl config schema --kind %s --provisioner %s", kind, provisioner, kind, provisioner),
+		Content:     []*yamlv3.Node{root},
+	}
+	b, err := encodeDocument(doc)
 	if err != nil {
 		return "", err
 	}
 	return string(b), nil
 }
 
-func createBase(config *Configuration) {
-	config.Metadata = Metadata{
-		Name: "nameOfTheProject # Used to identify your resources",
-		Labels: map[string]interface{}{
-			"yourLabel": "yourValue # You Can add as much labels as you required. You can add bool, string, number values",
-			"boolLabel": true,
-		},
-	}
-	config.Executor = TerraformExecutor{
-		StateConfiguration: StateConfiguration{
-			Backend: "local # Specify your backend configuration. local is the default one. https://www.terraform.io/docs/configuration/backend.html",
-			Config: map[string]string{
-				"path": "workdir/terraform.state # Set the backend configuration parameter. this path attribute works with the local backend.",
-			},
-		},
-	}
+// templater generates the yaml nodes of the configuration types from their documentation
+type templater struct {
+	// minimal skips the optional fields
+	minimal bool
+	// spec is the type of the spec of the configuration
+	spec reflect.Type
+	// values are the fixed values of the base fields
+	values map[string]string
 }
 
-func bootstrapTemplate(config *Configuration) error {
-	switch {
-	case config.Provisioner == "aws":
-		spec := bootstrapcfg.AWS{
-			NetworkCIDR:         "10.0.0.0/16 # Required. Specific VPC Network CIDR to create",
-			PublicSubnetsCIDRs:  []string{"Required", "10.0.10.0/24", "10.0.20.0/24", "10.0.30.0/24"},
-			PrivateSubnetsCIDRs: []string{"Required", "10.0.192.0/24", "10.0.182.0/24", "10.0.172.0/24"},
-			VPN: bootstrapcfg.AWSVPN{
-				Instances:     2,
-				Port:          1194,
-				InstanceType:  "t3.micro # This is the default value. Specify any AWS EC2 instance type",
-				DiskSize:      50,
-				OperatorName:  "sighup # This is the default value. SSH User to access the instance",
-				DHParamsBits:  2048,
-				SubnetCIDR:    "172.16.0.0/16 # Required attribute. Should be different from the networkCIDR",
-				SSHUsers:      []string{"Required", "angelbarrera92", "jnardiello"},
-				OperatorCIDRs: []string{"0.0.0.0/0", "This is the default value"},
-			},
-			Tags: map[string]string{
-				"myTag": "MyValue # Use this tags to annotate all resources. Optional",
-			},
+func (g *templater) object(t reflect.Type) (*yamlv3.Node, error) {
+	node := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+	base := t == reflect.TypeOf(Configuration{})
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" {
+			continue
+		}
+		f := lookupField(t, name)
+		fixed, isFixed := g.values[name]
+		isFixed = isFixed && base
+		if g.minimal && !f.required && !isFixed {
+			continue
 		}
-		config.Spec = spec
-	case config.Provisioner == "gcp":
-		spec := bootstrapcfg.GCP{
-			PublicSubnetsCIDRs:  []string{"Required", "10.0.1.0/24", "10.0.2.0/24"},
-			PrivateSubnetsCIDRs: []string{"Required", "10.0.11.0/24", "10.0.12.0/24"},
-			ClusterNetwork: bootstrapcfg.GCPClusterNetwork{
-				ControlPlaneCIDR:      "10.0.0.0/28 # Optional. Control Plane CIDR. This value is the default one",
-				SubnetworkCIDR:        "10.1.0.0/16 # Required. Cluster nodes subnetwork",
-				PodSubnetworkCIDR:     "10.2.0.0/16 # Required. Pod subnetwork CIDR",
-				ServiceSubnetworkCIDR: "10.3.0.0/16 # Required. Service subnetwork CIDR",
-			},
-			VPN: bootstrapcfg.GCPVPN{
-				Instances:     2,
-				Port:          1194,
-				InstanceType:  "n1-standard-1 # This is the default value. Specify any GCP instance type",
-				DiskSize:      50,
-				OperatorName:  "sighup # This is the default value. SSH User to access the instance",
-				DHParamsBits:  2048,
-				SubnetCIDR:    "172.16.0.0/16 # Required attribute. Should be different from the networkCIDR",
-				SSHUsers:      []string{"Required", "angelbarrera92", "jnardiello"},
-				OperatorCIDRs: []string{"0.0.0.0/0", "This is the default value"},
-			},
-			Tags: map[string]string{
-				"myTag": "MyValue # Use this tags to annotate all resources. Optional",
-			},
+		value := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: fixed}
+		if !isFixed {
+			fieldType := t.Field(i).Type
+			if base && name == "spec" {
+				fieldType = g.spec
+			}
+			var err error
+			if value, err = g.value(fieldType, f); err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
 		}
-		config.Spec = spec
-	default:
-		log.Errorf("Error creating a template configuration file. Parser not found for %v provisioner", config.Provisioner)
-		return fmt.Errorf("error creating a template configuration file. Parser not found for %v provisioner", config.Provisioner)
+		key := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: name, HeadComment: describeField(f)}
+		node.Content = append(node.Content, key, value)
 	}
-	createBase(config)
-	return nil
+	return node, nil
 }
 
-func clusterTemplate(config *Configuration) error {
-	switch {
-	case config.Provisioner == "eks":
-		spec := clustercfg.EKS{
-			Version:      "1.20 # EKS Control plane version",
-			Network:      "vpc-id1 # Identificator of the VPC",
-			SubNetworks:  []string{"subnet-id1 # Identificator of the subnets"},
-			DMZCIDRRange: clustercfg.DMZCIDRRange{Values: []string{"10.0.0.0/8", "Required. Network CIDR range from where cluster control plane will be accessible"}},
-			SSHPublicKey: "sha-rsa 190jd0132w. Required. Cluster administrator public ssh key. Used to access cluster nodes.",
-			Tags: map[string]string{
-				"myTag": "MyValue # Use this tags to annotate all resources. Optional",
-			},
-			Auth: clustercfg.EKSAuth{
-				AdditionalAccounts: []string{"777777777777", "88888888888", "# Additional AWS account numbers to add to the aws-auth configmap"},
-				Users: []clustercfg.EKSAuthData{
-					{
-						Username: "user1 # Any username",
-						Groups:   []string{"system:masters", "# Any K8S Group"},
-						UserARN:  "arn:user:7777777... # The user ARN",
-					},
-				},
-				Roles: []clustercfg.EKSAuthData{
-					{
-						Username: "user1 # Any username",
-						Groups:   []string{"system:masters", "# Any K8S Group"},
-						RoleARN:  "arn:role:7777777... # The role ARN",
-					},
-				},
-			},
-			NodePools: []clustercfg.EKSNodePool{
-				{
-					Name:         "my-node-pool. Required. Name of the node pool",
-					Version:      "1.18. Required. null to use Control Plane version.",
-					MinSize:      0,
-					MaxSize:      1,
-					InstanceType: "t3.micro. Required. AWS EC2 isntance types",
-					MaxPods:      110,
-					VolumeSize:   50,
-					SubNetworks:  []string{"subnet-1", "# any subnet id where you want your nodes running"},
-					Labels: map[string]string{
-						"environment": "dev. # Node labels. Use it to tag nodes then use it on Kubernetes",
-					},
-					OS:           "ami-12345 # The ami-id to use. Do not specify to use the default one",
-					TargetGroups: []string{"target-12345", "the target-id to associate the instance to a loadbalancer"},
-					Taints:       []string{"key1=value1:NoSchedule. As an example"},
-					Tags: map[string]string{
-						"myTag": "MyValue # Use this tags to annotate nodepool resources. Optional",
-					},
-					AdditionalFirewallRules: []clustercfg.EKSNodePoolFwRule{
-						{
-							Name:      "The name of rule # Identify the rule using a description",
-							Direction: "ingress|egress # Choose one",
-							CIDRBlock: "0.0.0.0/0 # CIDR Block",
-							Protocol:  "TCP|UDP # Any supported AWS security group protocol",
-							Ports:     "80-80 # Port range. This one means from 80 to 80",
-							Tags: map[string]string{
-								"myTag": "MyValue # Use this tags to annotate nodepool resources. Optional",
-							},
-						},
-					},
-				},
-			},
+// value returns the node of a field: its example when documented, the structs are generated field by field
+// and the lists of structs with a single item, any other value is its default or its zero value
+func (g *templater) value(t reflect.Type, f field) (*yamlv3.Node, error) {
+	if _, ok := customSchemas[t]; !ok && f.example == nil {
+		switch {
+		case t.Kind() == reflect.Struct:
+			return g.object(t)
+		case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct:
+			item, err := g.object(t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			return &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq", Content: []*yamlv3.Node{item}}, nil
 		}
-		config.Spec = spec
-	case config.Provisioner == "gke":
-		spec := clustercfg.GKE{
-			Version:                        "1.18.12-gke.1206 # GKE Control plane version",
-			Network:                        "vpc-id1 # Identificator of the Network",
-			NetworkProjectID:               "12309123 # OPTIONAL. The project ID of the shared VPC's host (for shared vpc support)",
-			ControlPlaneCIDR:               "10.0.0.0/28 # OPTIONAL. DEFAULT VALUE. The IP range in CIDR notation to use for the hosted master network",
-			AdditionalFirewallRules:        true,
-			AdditionalClusterFirewallRules: false,
-			DisableDefaultSNAT:             false,
-			SubNetworks: []string{
-				"subnet-id0 # Identificator of the subnets. Index 0: Cluster Subnet",
-				"subnet-id1 # Identificator of the subnets. Index 1: Pod Subnet",
-				"subnet-id2 # Identificator of the subnets. Index 1: Service Subnet",
-			},
+	}
+	v := f.example
+	if v == nil {
+		v = f.defaultValue
+	}
+	if v == nil {
+		v = reflect.Zero(t).Interface()
+	}
+	node := &yamlv3.Node{}
+	err := node.Encode(v)
+	return node, err
+}
 
-			DMZCIDRRange: clustercfg.DMZCIDRRange{Values: []string{"10.0.0.0/8", "Required. Network CIDR range from where cluster control plane will be accessible"}},
-			SSHPublicKey: "sha-rsa 190jd0132w. Required. Cluster administrator public ssh key. Used to access cluster nodes.",
-			Tags: map[string]string{
-				"myTag": "MyValue # Use this tags to annotate all resources. Optional",
-			},
+// describeField returns the comment of a field, as "Port of the VPN servers. Optional, defaults to 1194."
+func describeField(f field) string {
+	details := []string{"Optional"}
+	if f.required {
+		details[0] = "Required"
+	}
+	if f.defaultValue != nil {
+		details = append(details, "defaults to "+formatValue(f.defaultValue))
+	}
+	if len(f.enum) > 0 {
+		details = append(details, "one of: "+strings.Join(f.enum, ", "))
+	}
+	return fmt.Sprintf("%s. %s.", f.description, strings.Join(details, ", "))
+}
 
-			NodePools: []clustercfg.GKENodePool{
-				{
-					Name:         "my-node-pool. Required. Name of the node pool",
-					Version:      "1.18.12-gke.1206. Required. null to use Control Plane version.",
-					MinSize:      1,
-					MaxSize:      1,
-					InstanceType: "n1-standard-1. Required. GCP instance types",
-					OS:           "COS # The operating system to use. Do not specify to use the default one (COS)",
-					MaxPods:      110,
-					VolumeSize:   50,
-					SubNetworks:  []string{"subnet-1", "# availability zones (example: us-central1-a) where to place the nodes. Useful to don't create them on all zones"},
-					Labels: map[string]string{
-						"environment": "dev. # Node labels. Use it to tag nodes then use it on Kubernetes",
-					},
-					Taints: []string{"key1=value1:NoSchedule. As an example"},
-					Tags: map[string]string{
-						"myTag": "MyValue # Use this tags to annotate nodepool resources. Optional",
-					},
-					AdditionalFirewallRules: []clustercfg.GKENodePoolFwRule{
-						{
-							Name:      "The name of rule # Identify the rule using a description",
-							Direction: "ingress|egress # Choose one",
-							CIDRBlock: "0.0.0.0/0 # CIDR Block",
-							Protocol:  "TCP|UDP # Any supported GCP security group protocol",
-							Ports:     "80-80 # Port range. This one means from 80 to 80",
-							Tags: map[string]string{
-								"myTag": "MyValue # Use this tags to annotate nodepool resources. Optional",
-							},
-						},
-					},
-				},
-			},
-		}
-		config.Spec = spec
-	case config.Provisioner == "vsphere":
-		spec := clustercfg.VSphere{
-			Version:              "1.20.5 # Place here the Kubernetes version you want to use",
-			ControlPlaneEndpoint: "my-cluster.localdomain # OPTIONAL. Kubernetes control plane endpoint. Default to the VIP of the load balancer",
-			ETCDConfig: clustercfg.VSphereETCDConfig{
-				Version: "v3.4.15 # OPTIONAL. Place there the ETCD version you want to use",
-			},
-			OIDCConfig: clustercfg.VSphereOIDCConfig{
-				IssuerURL: "https://dex.internal.example.com/ # OPTIONAL. Place here the issuer URL of your oidc provider",
-				ClientID:  "oidc-auth-client # OPTIONAL. Place here the client ID",
-				CAFile:    "/etc/pki/ca-trust/source/anchors/example.com.cer # OPTIONAL. The CA certificate to use",
-			},
-			CRIConfig: clustercfg.VSphereCRIConfig{
-				Version: "18.06.2.ce # OPTIONAL. This is the default value for oracle linux docker CRI",
-				DNS:     []string{"1.1.1.1", "8.8.8.8", "# OPTIONAL. Set here your DNS servers"},
-				Proxy:   "\"HTTP_PROXY=http://systems.example.com:8080\" \"NO_PROXY=.example.com,.group.example.com\"",
-				Mirrors: []string{"https://mirror.gcr.io", "# OPTIONAL. Set here your dockerhub mirrors"},
-			},
-			EnvironmentName: "production # The environment name of the cluster",
-			Config: clustercfg.VSphereConfig{
-				DatacenterName: "westeros # Get the name of datacenter from vShpere dashboard",
-				Datastore:      "main # Get the name of datastore from vSphere dashboard",
-				EsxiHost:       []string{"host1", "host2", "host3", "# Names of the hosts where the VMs are going to be created"},
-			},
-			NetworkConfig: clustercfg.VSphereNetworkConfig{
-				Name:        "main-network # The name of the vSphere network",
-				Gateway:     "10.0.0.1 # The IP of the network gateway",
-				Nameservers: []string{"8.8.4.4", "1.1.1.1"},
-				Domain:      "localdomain",
-				IPOffset:    0,
-			},
-			Boundary: true,
-			LoadBalancerNode: clustercfg.VSphereKubeLoadBalancer{
-				Count:            1,
-				Template:         "ubuntu-20.04 # The name of the base image to use for the VMs",
-				CustomScriptPath: "./do-something.sh # A script that you want to run after first boot",
-			},
-			MasterNode: clustercfg.VSphereKubeNode{
-				Count:    1,
-				CPU:      1,
-				MemSize:  4096,
-				DiskSize: 100,
-				Template: "ubuntu-20.04 # The name of the base image to use for the VMs",
-				Labels: map[string]string{
-					"environment": "dev. # Node labels. Use it to tag nodes then use it on Kubernetes",
-				},
-				Taints: []string{"key1=value1:NoSchedule. As an example"},
-			},
-			InfraNode: clustercfg.VSphereKubeNode{
-				Count:    1,
-				CPU:      1,
-				MemSize:  8192,
-				DiskSize: 100,
-				Template: "ubuntu-20.04 # The name of the base image to use for the VMs",
-				Labels: map[string]string{
-					"environment": "dev. # Node labels. Use it to tag nodes then use it on Kubernetes",
-				},
-				Taints: []string{"key1=value1:NoSchedule. As an example"},
-			},
-			NodePools: []clustercfg.VSphereKubeNode{
-				{
-					Role:     "applications",
-					Count:    1,
-					CPU:      1,
-					MemSize:  8192,
-					DiskSize: 100,
-					Template: "ubuntu-20.04 # The name of the base image to use for the VMs",
-					Labels: map[string]string{
-						"environment": "dev. # Node labels. Use it to tag nodes then use it on Kubernetes",
-					},
-					Taints: []string{"key1=value1:NoSchedule. As an example"},
-				},
-			},
-			ClusterPODCIDR: "172.21.0.0/16",
-			ClusterSVCCIDR: "172.23.0.0/16",
-			ClusterCIDR:    "10.2.0.0/16",
-			SSHPublicKey: []string{
-				"/home/foo/.ssh/id_rsa.pub",
-			},
-		}
-		config.Spec = spec
-	default:
-		log.Errorf("error creating a template configuration file. Parser not found for %v provisioner", config.Provisioner)
-		return fmt.Errorf("error creating a template configuration file. Parser not found for %v provisioner", config.Provisioner)
+func formatValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
 	}
-	createBase(config)
-	return nil
+	return string(b)
 }
@@ -0,0 +1,317 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"fmt"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ValidationError is a configuration value not matching the schema, with its position in the file
+type ValidationError struct {
+	File    string `json:"file,omitempty"`
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Message string `json:"message"`
+	// unknown marks the fields missing from the schema, reported as warnings when they are allowed
+	unknown bool
+}
+
+func (e ValidationError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "<root>"
+	}
+	if e.File != "" {
+		return fmt.Sprintf("%s:%d:%d: %s: %s", e.File, e.Line, e.Column, path, e.Message)
+	}
+	return fmt.Sprintf("line %d, column %d: %s: %s", e.Line, e.Column, path, e.Message)
+}
+
+// ValidationErrors are all the errors found validating a configuration file
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return "invalid configuration:\n" + strings.Join(messages, "\n")
+}
+
+// Validate checks a yaml document against the schema, returning ValidationErrors when it does not match
+func (s *JSONSchema) Validate(content []byte, file string) error {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return ValidationErrors{{File: file, Line: 1, Column: 1, Message: "empty configuration"}}
+	}
+	errs := s.validateDocument(doc.Content[0], false)
+	if len(errs) == 0 {
+		return nil
+	}
+	for i := range errs {
+		errs[i].File = file
+	}
+	return errs
+}
+
+// validator collects the errors found checking a yaml document against a schema
+type validator struct {
+	// partial accepts the objects missing some required fields, as the overlays
+	partial bool
+	errs    ValidationErrors
+}
+
+func (s *JSONSchema) validateDocument(root *yamlv3.Node, partial bool) ValidationErrors {
+	v := &validator{partial: partial}
+	s.validate(root, "", v)
+	return v.errs
+}
+
+// unknownFields splits the unknown fields from the other errors
+func (e ValidationErrors) unknownFields() (errs ValidationErrors, unknown ValidationErrors) {
+	for _, err := range e {
+		if err.unknown {
+			unknown = append(unknown, err)
+		} else {
+			errs = append(errs, err)
+		}
+	}
+	return errs, unknown
+}
+
+// locate sets the file, line and column of the errors looking up their path in the yaml document.
+// The file of a node missing from files is the default one
+func (e ValidationErrors) locate(root *yamlv3.Node, files map[*yamlv3.Node]string, file string) {
+	positions := map[string]*yamlv3.Node{}
+	indexNodes(root, "", positions)
+	for i := range e {
+		e[i].File = file
+		// a single value can stand for a list, as the dmzCIDRRange, so the closest parent is used
+		path := e[i].Path
+		for positions[path] == nil && path != "" {
+			path = path[:strings.LastIndexAny(path, ".[")+1]
+			path = strings.TrimSuffix(strings.TrimSuffix(path, "."), "[")
+		}
+		if node, ok := positions[path]; ok {
+			e[i].Line, e[i].Column = node.Line, node.Column
+			if f, ok := files[node]; ok {
+				e[i].File = f
+			}
+		}
+	}
+}
+
+func indexNodes(node *yamlv3.Node, path string, positions map[string]*yamlv3.Node) {
+	if node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	positions[path] = node
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			indexNodes(node.Content[i+1], joinPath(path, node.Content[i].Value), positions)
+		}
+	case yamlv3.SequenceNode:
+		for i, item := range node.Content {
+			indexNodes(item, fmt.Sprintf("%s[%d]", path, i), positions)
+		}
+	}
+}
+
+func (s *JSONSchema) validate(node *yamlv3.Node, path string, v *validator) {
+	if node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	fail := func(format string, args ...interface{}) {
+		v.errs = append(v.errs, ValidationError{Path: path, Line: node.Line, Column: node.Column, Message: fmt.Sprintf(format, args...)})
+	}
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!null" {
+		// missing values are decoded as zero values
+		return
+	}
+
+	if len(s.OneOf) > 0 {
+		types := make([]string, 0, len(s.OneOf))
+		for _, option := range s.OneOf {
+			optionValidator := &validator{partial: v.partial}
+			option.validate(node, path, optionValidator)
+			if len(optionValidator.errs) == 0 {
+				return
+			}
+			types = append(types, article(option.Type))
+		}
+		fail("expected %s, got %s", strings.Join(types, " or "), describe(node))
+		return
+	}
+
+	switch s.Type {
+	case "object":
+		if node.Kind != yamlv3.MappingNode {
+			fail("expected an object, got %s", describe(node))
+			return
+		}
+		present := map[string]bool{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			present[key] = true
+			property, ok := s.Properties[key]
+			if !ok {
+				property = s.AdditionalProperties
+			}
+			if property != nil {
+				property.validate(value, joinPath(path, key), v)
+			} else if s.closed {
+				v.errs = append(v.errs, ValidationError{
+					Path:    joinPath(path, key),
+					Line:    node.Content[i].Line,
+					Column:  node.Content[i].Column,
+					Message: s.unknownField(path, key),
+					unknown: true,
+				})
+			}
+		}
+		for _, required := range s.Required {
+			if !present[required] && !v.partial {
+				fail("missing required field %s", required)
+			}
+		}
+	case "array":
+		if node.Kind != yamlv3.SequenceNode {
+			fail("expected an array, got %s", describe(node))
+			return
+		}
+		for i, item := range node.Content {
+			s.Items.validate(item, fmt.Sprintf("%s[%d]", path, i), v)
+		}
+	case "string":
+		// yaml scalars are decoded into strings whatever their type
+		if node.Kind != yamlv3.ScalarNode {
+			fail("expected a string, got %s", describe(node))
+		}
+	case "integer":
+		if node.Kind != yamlv3.ScalarNode || node.Tag != "!!int" {
+			fail("expected an integer, got %s", describe(node))
+		}
+	case "number":
+		if node.Kind != yamlv3.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			fail("expected a number, got %s", describe(node))
+		}
+	case "boolean":
+		if node.Kind != yamlv3.ScalarNode || !isBool(node) {
+			fail("expected a boolean, got %s", describe(node))
+		}
+	}
+
+	if s.Const != "" && node.Kind == yamlv3.ScalarNode && node.Value != s.Const {
+		fail("expected %s, got %s", s.Const, node.Value)
+	}
+	if len(s.Enum) > 0 && node.Kind == yamlv3.ScalarNode && !contains(s.Enum, node.Value) {
+		fail("expected one of %s, got %s", strings.Join(s.Enum, ", "), node.Value)
+	}
+}
+
+// unknownField describes a field missing from the schema, suggesting the closest known one
+func (s *JSONSchema) unknownField(path, key string) string {
+	if reason, ok := removedFields[joinPath(path, key)]; ok {
+		return fmt.Sprintf("unknown field %s, %s", key, reason)
+	}
+	suggestion, best := "", len(key)/2+1
+	for name := range s.Properties {
+		d := distance(strings.ToLower(key), strings.ToLower(name))
+		if d < best || (d == best && suggestion != "" && name < suggestion) {
+			suggestion, best = name, d
+		}
+	}
+	if suggestion == "" {
+		return fmt.Sprintf("unknown field %s", key)
+	}
+	return fmt.Sprintf("unknown field %s, did you mean %s?", key, suggestion)
+}
+
+// distance is the Levenshtein distance between two strings
+func distance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isBool accepts the yaml 1.1 booleans too, as the configuration decoder does
+func isBool(node *yamlv3.Node) bool {
+	if node.Tag == "!!bool" {
+		return true
+	}
+	switch strings.ToLower(node.Value) {
+	case "y", "yes", "n", "no", "on", "off":
+		return node.Style == 0
+	}
+	return false
+}
+
+func describe(node *yamlv3.Node) string {
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		return "an object"
+	case yamlv3.SequenceNode:
+		return "an array"
+	default:
+		return fmt.Sprintf("%q", node.Value)
+	}
+}
+
+func article(t string) string {
+	switch t {
+	case "object", "array", "integer":
+		return "an " + t
+	default:
+		return "a " + t
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+const (
+	// APIVersion is the latest version of the configuration files
+	APIVersion = "furyctl.sighup.io/v1alpha2"
+	// legacyAPIVersion is the version of the configuration files without an apiVersion
+	legacyAPIVersion = "furyctl.sighup.io/v1alpha1"
+)
+
+// apiVersion contains the types of a version of the configuration files and the conversion to the next one
+type apiVersion struct {
+	name  string
+	base  interface{}
+	specs map[string]map[string]interface{}
+	// convert rewrites a document of a kind and provisioner to the next version in place, returning a note for every change
+	convert func(doc *yamlv3.Node, kind, provisioner string) []string
+}
+
+// apiVersions are the supported versions of the configuration files, from the oldest to the latest one
+var apiVersions = []apiVersion{
+	{
+		name:    legacyAPIVersion,
+		base:    v1alpha1Configuration{},
+		specs:   specs,
+		convert: convertV1alpha1,
+	},
+	{
+		name:  APIVersion,
+		base:  Configuration{},
+		specs: specs,
+	},
+}
+
+// v1alpha1Configuration is the base of the v1alpha1 configuration files
+type v1alpha1Configuration struct {
+	APIVersion  string                    `yaml:"apiVersion"`
+	Kind        string                    `yaml:"kind"`
+	Metadata    Metadata                  `yaml:"metadata"`
+	Spec        interface{}               `yaml:"spec"`
+	Executor    v1alpha1TerraformExecutor `yaml:"executor"`
+	Provisioner string                    `yaml:"provisioner"`
+}
+
+// v1alpha1TerraformExecutor still accepts the terraform version and path, ignored since furyctl v0.5.1
+type v1alpha1TerraformExecutor struct {
+	Version            string             `yaml:"version"`
+	Path               string             `yaml:"path"`
+	StateConfiguration StateConfiguration `yaml:"state"`
+}
+
+// removedFields explains the unknown fields of the latest version that older versions accepted
+var removedFields = map[string]string{
+	"executor.version": "removed in " + APIVersion + ", furyctl always uses its own terraform binary",
+	"executor.path":    "removed in " + APIVersion + ", furyctl always uses its own terraform binary",
+}
+
+func convertV1alpha1(doc *yamlv3.Node, kind, provisioner string) (notes []string) {
+	if executor := mappingValue(doc, "executor"); executor != nil {
+		for _, key := range []string{"version", "path"} {
+			if deleteKey(executor, key) {
+				notes = append(notes, fmt.Sprintf("removed executor.%s, furyctl always uses its own terraform binary", key))
+			}
+		}
+		if len(executor.Content) == 0 {
+			deleteKey(doc, "executor")
+		}
+	}
+	return notes
+}
+
+// APIVersions returns the supported versions of the configuration files, from the oldest to the latest one
+func APIVersions() []string {
+	names := make([]string, 0, len(apiVersions))
+	for _, v := range apiVersions {
+		names = append(names, v.name)
+	}
+	return names
+}
+
+func lookupAPIVersion(name string) (int, error) {
+	if name == "" {
+		name = legacyAPIVersion
+	}
+	for i, v := range apiVersions {
+		if v.name == name {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown apiVersion %s. Choose one of: %s", name, strings.Join(APIVersions(), ", "))
+}
+
+// upgrade converts a document of a kind and provisioner to the latest version in place,
+// returning its original version and the notes of the conversions
+func upgrade(doc *yamlv3.Node, kind, provisioner string) (string, []string, error) {
+	i, err := lookupAPIVersion(scalarValue(doc, "apiVersion"))
+	if err != nil {
+		return "", nil, err
+	}
+	version := apiVersions[i].name
+	var notes []string
+	for ; i < len(apiVersions)-1; i++ {
+		notes = append(notes, apiVersions[i].convert(doc, kind, provisioner)...)
+	}
+	setAPIVersion(doc, APIVersion)
+	return version, notes, nil
+}
+
+// Migrate rewrites a configuration file to the latest version keeping its comments,
+// returning its original version and a note for every change
+func Migrate(content []byte) ([]byte, string, []string, error) {
+	var doc yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &doc); err != nil {
+		return nil, "", nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yamlv3.MappingNode {
+		return nil, "", nil, fmt.Errorf("the configuration file is not a yaml object")
+	}
+	root := doc.Content[0]
+	version, notes, err := upgrade(root, scalarValue(root, "kind"), scalarValue(root, "provisioner"))
+	if err != nil {
+		return nil, "", nil, err
+	}
+	migrated, err := encodeDocument(&doc)
+	return migrated, version, notes, err
+}
+
+func encodeDocument(doc *yamlv3.Node) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yamlv3.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// setAPIVersion sets the apiVersion of a document, adding it as its first key when missing
+func setAPIVersion(doc *yamlv3.Node, version string) {
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value == "apiVersion" {
+			doc.Content[i+1].SetString(version)
+			return
+		}
+	}
+	key := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: "apiVersion"}
+	value := &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: version}
+	if len(doc.Content) > 0 {
+		// the comment at the top of the file belongs to the new first key
+		key.HeadComment, doc.Content[0].HeadComment = doc.Content[0].HeadComment, ""
+	}
+	doc.Content = append([]*yamlv3.Node{key, value}, doc.Content...)
+}
+
+func mappingValue(node *yamlv3.Node, key string) *yamlv3.Node {
+	if node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func scalarValue(node *yamlv3.Node, key string) string {
+	if value := mappingValue(node, key); value != nil && value.Kind == yamlv3.ScalarNode {
+		return value.Value
+	}
+	return ""
+}
+
+func deleteKey(node *yamlv3.Node, key string) bool {
+	if node.Kind != yamlv3.MappingNode {
+		return false
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package configuration
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	clustercfg "github.com/sighupio/furyctl/internal/cluster/configuration"
+)
+
+const v1alpha1VSphereCluster = `# Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+
+kind: Cluster
+metadata:
+  name: demo
+executor:
+  version: 0.12.29 # pinned by the old releases
+  state:
+    backend: local
+provisioner: vsphere
+spec:
+  version: 1.20.5
+  clusterCIDR: 10.2.0.0/16
+  # pods and services must not overlap
+  clusterPODCIDR: 172.21.0.0/16
+  clusterSVCCIDR: 172.23.0.0/16 # services
+  networkConfig:
+    nameservers:
+      - 10.2.0.2
+`
+
+func TestMigrate(t *testing.T) {
+	migrated, version, notes, err := Migrate([]byte(v1alpha1VSphereCluster))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != legacyAPIVersion {
+		t.Errorf("Migrate() version = %s, want %s", version, legacyAPIVersion)
+	}
+	wantNotes := []string{
+		"removed executor.version, furyctl always uses its own terraform binary",
+	}
+	if !reflect.DeepEqual(notes, wantNotes) {
+		t.Errorf("Migrate() notes =\n%v\nwant\n%v", notes, wantNotes)
+	}
+	want := `# Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+
+apiVersion: furyctl.sighup.io/v1alpha2
+kind: Cluster
+metadata:
+  name: demo
+executor:
+  state:
+    backend: local
+provisioner: vsphere
+spec:
+  version: 1.20.5
+  clusterCIDR: 10.2.0.0/16
+  # pods and services must not overlap
+  clusterPODCIDR: 172.21.0.0/16
+  clusterSVCCIDR: 172.23.0.0/16 # services
+  networkConfig:
+    nameservers:
+      - 10.2.0.2
+`
+	if string(migrated) != want {
+		t.Errorf("Migrate() =\n%s\nwant\n%s", migrated, want)
+	}
+
+	again, version, notes, err := Migrate(migrated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if version != APIVersion || len(notes) != 0 || string(again) != want {
+		t.Errorf("Migrate() of the latest version changed the file: %s %v\n%s", version, notes, again)
+	}
+}
+
+func TestParseLegacyAPIVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-configuration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "cluster.yml")
+	if err = ioutil.WriteFile(path, []byte(v1alpha1VSphereCluster), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var warnings []string
+	opts := Options{Warn: func(w ValidationError) { warnings = append(warnings, w.Message) }}
+	r, source := readFile(t, path)
+	config, err := ParseWithOptions(r, source, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := config.Spec.(clustercfg.VSphere)
+	if config.APIVersion != APIVersion || spec.ClusterPODCIDR != "172.21.0.0/16" || spec.ClusterSVCCIDR != "172.23.0.0/16" {
+		t.Errorf("Parse() did not convert the legacy configuration: %+v", config)
+	}
+	want := []string{
+		"deprecated apiVersion furyctl.sighup.io/v1alpha1, run furyctl config migrate to update it to furyctl.sighup.io/v1alpha2",
+		"removed executor.version, furyctl always uses its own terraform binary",
+	}
+	if !reflect.DeepEqual(warnings, want) {
+		t.Errorf("Parse() warnings =\n%v\nwant\n%v", warnings, want)
+	}
+
+	// a legacy file without anything to convert is read silently
+	unchanged := strings.Replace(v1alpha1VSphereCluster, "  version: 0.12.29 # pinned by the old releases\n", "", 1)
+	if err = ioutil.WriteFile(path, []byte(unchanged), 0644); err != nil {
+		t.Fatal(err)
+	}
+	warnings = nil
+	r, source = readFile(t, path)
+	if _, err = ParseWithOptions(r, source, opts); err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("Parse() of a legacy file without changes warnings = %v, want none", warnings)
+	}
+}
+
+func TestUnknownAPIVersion(t *testing.T) {
+	if _, _, _, err := Migrate([]byte("apiVersion: furyctl.sighup.io/v2\nkind: Cluster\n")); err == nil {
+		t.Error("Migrate() of an unknown apiVersion should fail")
+	}
+	if _, err := VersionSchema(legacyAPIVersion, "Cluster", "vsphere"); err != nil {
+		t.Error(err)
+	}
+}
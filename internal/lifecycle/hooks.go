@@ -0,0 +1,122 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lifecycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/sighupio/furyctl/internal/shell"
+	log "github.com/sirupsen/logrus"
+)
+
+// hooksLogFile is the file of the project the output of the hooks is appended to
+const hooksLogFile = "logs/hooks.log"
+
+// invalidEnvChars matches the characters of the terraform output names not allowed in the environment variables
+var invalidEnvChars = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// hooks returns the step running the commands of a hook, none when it has no commands
+func (e *Engine) hooks(name string, commands []string) []step {
+	if len(commands) == 0 {
+		return nil
+	}
+	return []step{{
+		phase:   Phase(name + " hooks"),
+		message: fmt.Sprintf("Running the %s hooks", name),
+		run: func() error {
+			return e.runHooks(name, commands)
+		},
+	}}
+}
+
+// runHooks runs the commands of a hook in order from the working directory, stopping at the first failing one.
+// Their output is appended to the hooks log file of the project
+func (e *Engine) runHooks(name string, commands []string) error {
+	timeout, err := e.options.ProvisionerConfiguration.Hooks.TimeoutDuration()
+	if err != nil {
+		return err
+	}
+	env, err := e.hookEnv(name)
+	if err != nil {
+		return err
+	}
+	logPath := filepath.Join(e.project.Path, hooksLogFile)
+	if err = os.MkdirAll(filepath.Dir(logPath), 0700); err != nil {
+		return err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	for _, command := range commands {
+		log.Infof("running %s hook: %s", name, command)
+		if _, err = fmt.Fprintf(logFile, "[%s] %s: %s\n", time.Now().Format(time.RFC3339), name, command); err != nil {
+			return err
+		}
+		if err = shell.Run(command, "", timeout, env, logFile); err != nil {
+			return fmt.Errorf("%s hook %s failed: %v. Take a look to %s", name, command, err, logPath)
+		}
+	}
+	return nil
+}
+
+// hookEnv returns the environment of the hooks: the furyctl one, the project and the non-sensitive terraform outputs
+// saved by the last apply, as FURYCTL_OUTPUT_<NAME>. The postDestroy hooks get no outputs, their resources are gone
+func (e *Engine) hookEnv(name string) ([]string, error) {
+	config := e.options.ProvisionerConfiguration
+	projectPath := filepath.Clean(e.project.Path)
+	env := append(os.Environ(),
+		"FURYCTL_HOOK="+name,
+		"FURYCTL_KIND="+config.Kind,
+		"FURYCTL_PROVISIONER="+config.Provisioner,
+		"FURYCTL_PROJECT_NAME="+config.Metadata.Name,
+		"FURYCTL_PROJECT_PATH="+projectPath,
+	)
+	if e.kind.Kubeconfig {
+		env = append(env, "FURYCTL_KUBECONFIG="+filepath.Join(projectPath, "secrets", "kubeconfig"))
+	}
+
+	if name == "postDestroy" {
+		return env, nil
+	}
+	content, err := ioutil.ReadFile(filepath.Join(projectPath, "output", "output.json"))
+	if os.IsNotExist(err) {
+		return env, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var outputs map[string]tfexec.OutputMeta
+	if err = json.Unmarshal(content, &outputs); err != nil {
+		return nil, fmt.Errorf("error reading the terraform outputs: %v", err)
+	}
+	for output, meta := range outputs {
+		if meta.Sensitive {
+			continue
+		}
+		// the strings are unquoted, any other value is compact json
+		var value string
+		if json.Unmarshal(meta.Value, &value) != nil {
+			var compact bytes.Buffer
+			if err = json.Compact(&compact, meta.Value); err != nil {
+				return nil, fmt.Errorf("error reading the terraform output %s: %v", output, err)
+			}
+			value = compact.String()
+		}
+		env = append(env, "FURYCTL_OUTPUT_"+invalidEnvChars.ReplaceAllString(strings.ToUpper(output), "_")+"="+value)
+	}
+	return env, nil
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lifecycle
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/internal/project"
+)
+
+func TestRunHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-lifecycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	outputs := `{
+    "cluster_endpoint": {"sensitive": false, "type": "string", "value": "https://10.0.0.1"},
+    "node_pools": {"sensitive": false, "type": ["list", "string"], "value": ["infra", "app"]},
+    "admin_password": {"sensitive": true, "type": "string", "value": "secret"}
+}`
+	if err = os.MkdirAll(filepath.Join(dir, "output"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "output", "output.json"), []byte(outputs), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	config := &configuration.Configuration{Kind: "Cluster", Provisioner: "eks", Metadata: configuration.Metadata{Name: "demo"}}
+	e := &Engine{
+		kind:    Kind{Name: "cluster", Kubeconfig: true},
+		options: &Options{ProvisionerConfiguration: config},
+		project: &project.Project{Path: dir},
+	}
+	if steps := e.hooks("preApply", nil); len(steps) != 0 {
+		t.Errorf("hooks() without commands = %d steps, want none", len(steps))
+	}
+
+	err = e.runHooks("postApply", []string{
+		"echo $FURYCTL_HOOK $FURYCTL_KIND $FURYCTL_PROVISIONER $FURYCTL_PROJECT_NAME",
+		"echo $FURYCTL_KUBECONFIG",
+		"echo $FURYCTL_OUTPUT_CLUSTER_ENDPOINT $FURYCTL_OUTPUT_NODE_POOLS ${FURYCTL_OUTPUT_ADMIN_PASSWORD:-hidden}",
+	})
+	if err != nil {
+		t.Fatalf("runHooks() error = %v", err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, hooksLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"postApply: echo $FURYCTL_HOOK",
+		"postApply Cluster eks demo\n",
+		filepath.Join(dir, "secrets", "kubeconfig") + "\n",
+		`https://10.0.0.1 ["infra","app"] hidden` + "\n",
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("hooks log =\n%s\nwant %q", content, want)
+		}
+	}
+
+	// the outputs of the destroyed resources are not exported
+	if err = e.runHooks("postDestroy", []string{"echo destroyed ${FURYCTL_OUTPUT_CLUSTER_ENDPOINT:-without outputs}"}); err != nil {
+		t.Fatalf("runHooks() error = %v", err)
+	}
+	content, err = ioutil.ReadFile(filepath.Join(dir, hooksLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "destroyed without outputs\n") {
+		t.Errorf("postDestroy hooks log =\n%s\nwant no outputs", content)
+	}
+
+	err = e.runHooks("preDestroy", []string{"exit 3", "echo not run"})
+	if err == nil || !strings.Contains(err.Error(), "preDestroy hook exit 3 failed") {
+		t.Errorf("runHooks() error = %v, want the failing hook", err)
+	}
+	content, _ = ioutil.ReadFile(filepath.Join(dir, hooksLogFile))
+	if strings.Contains(string(content), "not run\n") {
+		t.Errorf("runHooks() ran the hooks after a failing one:\n%s", content)
+	}
+
+	config.Hooks.Timeout = "100ms"
+	if err = e.runHooks("preInit", []string{"sleep 10"}); err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("runHooks() error = %v, want a timeout", err)
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package lifecycle runs the phases shared by the cluster and the bootstrap projects: prepare the project,
+// install the provisioner files, initialize the terraform executor and the terraform project, then plan, apply or
+// destroy the infrastructure and save its outputs
+package lifecycle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/briandowns/spinner"
+	"github.com/hashicorp/terraform-exec/tfexec"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/internal/project"
+	"github.com/sighupio/furyctl/internal/provisioners"
+	"github.com/sighupio/furyctl/pkg/terraform"
+	log "github.com/sirupsen/logrus"
+)
+
+// Phase is a step of the lifecycle of a project
+type Phase string
+
+// The phases of the lifecycle, in the order they run
+const (
+	PrepareProject     Phase = "prepare project"
+	InitExecutor       Phase = "init executor"
+	InstallFiles       Phase = "install files"
+	PrepareProvisioner Phase = "prepare provisioner"
+	TerraformInit      Phase = "terraform init"
+	Plan               Phase = "plan"
+	Apply              Phase = "apply"
+	Destroy            Phase = "destroy"
+	Outputs            Phase = "outputs"
+)
+
+// List of default subdirectories needed to run any provisioner.
+var projectDefaultSubDirs = []string{"logs", "configuration", "output", "bin", "secrets"}
+
+// Kind contains what differs between the lifecycles of the kinds of projects
+type Kind struct {
+	// Name is the kind in the messages and the furyctl commands: cluster or bootstrap
+	Name string
+	// Kubeconfig tells whether the provisioners return the kubeconfig of a cluster, written to secrets/kubeconfig
+	Kubeconfig bool
+}
+
+// Options are valid configuration needed to proceed with the project management
+type Options struct {
+	Spin                     *spinner.Spinner
+	Project                  *project.Project
+	ProvisionerConfiguration *configuration.Configuration
+	TerraformOpts            *terraform.Options
+}
+
+// Engine runs the lifecycle of a project of a kind
+type Engine struct {
+	kind    Kind
+	options *Options
+	s       *spinner.Spinner
+
+	project     *project.Project
+	provisioner provisioners.Provisioner
+}
+
+// step runs a phase, the spinner shows its message meanwhile
+type step struct {
+	phase   Phase
+	message string
+	run     func() error
+	// the lenient steps log their errors as warnings, the project already exists
+	lenient bool
+}
+
+// New builds the lifecycle engine of a project of a kind
+func New(kind Kind, opts *Options) (*Engine, error) {
+	// Grab the right provisioner
+	p, err := provisioners.Get(*opts.ProvisionerConfiguration)
+	if err != nil {
+		log.Errorf("error creating the %s instance while adquiring the right provisioner: %v", kind.Name, err)
+		return nil, err
+	}
+	if p.Enterprise() && opts.TerraformOpts.GitHubToken == "" {
+		log.Warningf("The %v provisioner is an enterprise feature and requires a valid GitHub token", opts.ProvisionerConfiguration.Provisioner)
+	}
+
+	opts.TerraformOpts.Version = "0.15.4"
+	opts.TerraformOpts.LogDir = "logs"
+	opts.TerraformOpts.ConfigDir = "configuration"
+	opts.TerraformOpts.Backend = opts.ProvisionerConfiguration.Executor.StateConfiguration.Backend
+	opts.TerraformOpts.BackendConfig = opts.ProvisionerConfiguration.Executor.StateConfiguration.Config
+
+	return &Engine{
+		kind:        kind,
+		options:     opts,
+		s:           opts.Spin,
+		project:     opts.Project,
+		provisioner: p,
+	}, nil
+}
+
+// Init intializes a project directory with all files (terraform project, subdirectories...) running terraform init on it
+func (e *Engine) Init(reset bool) (err error) {
+	// Enterprise token validation
+	if e.provisioner.Enterprise() && e.options.TerraformOpts.GitHubToken == "" {
+		errorMsg := fmt.Sprintf("error creating the %s instance. The %v provisioner is an enterprise feature and requires a valid GitHub token. Contact sales@sighup.io", e.kind.Name, e.options.ProvisionerConfiguration.Provisioner)
+		log.Error(errorMsg)
+		return errors.New(errorMsg)
+	}
+
+	// Reset the project directory
+	if reset {
+		log.Warn("Cleaning up the workdir")
+		err = e.project.Reset()
+		if err != nil {
+			log.Errorf("Error cleaning up the workdir")
+			return err
+		}
+	}
+
+	hooks := e.options.ProvisionerConfiguration.Hooks
+	steps := []step{{phase: PrepareProject, message: "Creating project structure", run: e.prepareProject}}
+	// the hooks log to the project directory, created by the first phase
+	steps = append(steps, e.hooks("preInit", hooks.PreInit)...)
+	steps = append(steps,
+		step{phase: InitExecutor, message: "Initializing the terraform executor", run: e.initTerraformExecutor},
+		step{phase: InstallFiles, message: "Installing provisioner terraform files", run: e.installProvisionerTerraformFiles},
+		step{phase: PrepareProvisioner, message: "Preparing the provisioner environment", run: e.provisioner.Prepare},
+		step{phase: TerraformInit, message: "Initializing terraform project", run: e.terraformInit},
+	)
+	if err = e.run(steps); err != nil {
+		return err
+	}
+	e.postInit()
+	return nil
+}
+
+// Update applies the terraform project, or plans it when dryrun is set, and saves its outputs.
+// The apply hooks are skipped by the dry runs
+func (e *Engine) Update(dryrun bool) (err error) {
+	hooks := e.options.ProvisionerConfiguration.Hooks
+	steps := e.updateSteps()
+	if dryrun {
+		steps = append(steps, step{phase: Plan, message: "[DRYRUN] Applying terraform project", run: e.provisioner.Plan})
+		if err = e.run(steps); err != nil {
+			return err
+		}
+		log.Infof("[DRYRUN] Discover the resulting plan in the %v/logs/terraform.logs file", e.project.Path)
+		e.postPlan()
+		return nil
+	}
+
+	var kubeconfig string
+	steps = append(e.hooks("preApply", hooks.PreApply), steps...)
+	steps = append(steps,
+		step{phase: Apply, message: "Applying terraform project", run: func() (err error) {
+			kubeconfig, err = e.provisioner.Update()
+			return err
+		}},
+		step{phase: Outputs, message: "Saving outputs", run: func() error {
+			return e.saveOutputs(kubeconfig)
+		}},
+	)
+	steps = append(steps, e.hooks("postApply", hooks.PostApply)...)
+	if err = e.run(steps); err != nil {
+		return err
+	}
+	e.postUpdate()
+	return nil
+}
+
+// Destroy destroys the infrastructure of the project (terraform destroy)
+func (e *Engine) Destroy() (err error) {
+	hooks := e.options.ProvisionerConfiguration.Hooks
+	steps := append(e.hooks("preDestroy", hooks.PreDestroy), e.updateSteps()...)
+	steps = append(steps, step{phase: Destroy, message: "Destroying terraform project", run: e.provisioner.Destroy})
+	steps = append(steps, e.hooks("postDestroy", hooks.PostDestroy)...)
+	if err = e.run(steps); err != nil {
+		return err
+	}
+	e.postDestroy()
+	return nil
+}
+
+// updateSteps prepare an existing project, reinstalling its files and reinitializing terraform
+func (e *Engine) updateSteps() []step {
+	return []step{
+		{phase: PrepareProject, message: "Updating project structure", run: e.updateProject},
+		{phase: InitExecutor, message: "Initializing the terraform executor", run: e.initTerraformExecutor},
+		{phase: InstallFiles, message: "Updating provisioner terraform files", run: e.installProvisionerTerraformFiles, lenient: true},
+		{phase: TerraformInit, message: "Re-Initializing terraform project", run: e.terraformInit},
+	}
+}
+
+// run runs the steps in order, stopping at the first failing one
+func (e *Engine) run(steps []step) error {
+	for _, s := range steps {
+		e.s.Stop()
+		e.s.Suffix = " " + s.message
+		e.s.Start()
+		err := s.run()
+		if err != nil && s.lenient {
+			log.Warnf("error while running the %s phase of the %s project: %v", s.phase, e.kind.Name, err)
+			continue
+		}
+		if err != nil {
+			e.s.Stop()
+			log.Errorf("error while running the %s phase of the %s project. Take a look to the logs. %v", s.phase, e.kind.Name, err)
+			return err
+		}
+	}
+	e.s.Stop()
+	return nil
+}
+
+// prepareProject creates the subdirectories and the git files of a new project
+func (e *Engine) prepareProject() error {
+	err := e.project.CreateSubDirs(projectDefaultSubDirs)
+	if err != nil {
+		return err
+	}
+	return e.createGitFiles()
+}
+
+// updateProject creates the missing subdirectories and rewrites the git files of an existing project
+func (e *Engine) updateProject() error {
+	err := e.project.CreateSubDirs(projectDefaultSubDirs)
+	if err != nil {
+		log.Warnf("error while updating project subdirectories: %v", err)
+	}
+	return e.createGitFiles()
+}
+
+func (e *Engine) terraformInit() error {
+	return e.provisioner.TerraformExecutor().Init(context.Background(), tfexec.Reconfigure(e.options.TerraformOpts.ReconfigureBackend))
+}
+
+// saveOutputs writes the terraform outputs and, for the kinds returning it, the kubeconfig
+func (e *Engine) saveOutputs(kubeconfig string) error {
+	output, err := e.output()
+	if err != nil {
+		return err
+	}
+	err = e.project.WriteFile("output/output.json", output)
+	if err != nil {
+		return err
+	}
+	if e.kind.Kubeconfig {
+		return e.project.WriteFile("secrets/kubeconfig", []byte(kubeconfig))
+	}
+	return nil
+}
+
+// installs/copy files from the provisioner to the working dir
+func (e *Engine) installProvisionerTerraformFiles() (err error) {
+	b := e.provisioner.Box()
+	for _, tfFileName := range e.provisioner.TerraformFiles() {
+		tfFile, err := b.Find(tfFileName)
+		if err != nil {
+			log.Errorf("Error while finding the right file in the box: %v", err)
+			return err
+		}
+		err = e.project.WriteFile(tfFileName, tfFile)
+		if err != nil {
+			log.Errorf("Error while writing the binary data from the box to the project dir: %v", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// creates the terraform executor to being used by the engine and its provisioner
+func (e *Engine) initTerraformExecutor() (err error) {
+	tf, err := terraform.NewExecutor(*e.options.TerraformOpts)
+	if err != nil {
+		return err
+	}
+
+	// Attach the terraform executor to the provisioner
+	e.provisioner.SetTerraformExecutor(tf)
+	return nil
+}
+
+// Output gathers the Output in form of binary data
+func (e *Engine) output() ([]byte, error) {
+	log.Info("Gathering output file as json")
+	var output map[string]tfexec.OutputMeta
+	output, err := e.provisioner.TerraformExecutor().Output(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(output, "", "    ")
+}
+
+func (e *Engine) createGitFiles() error {
+	gitattributes := `*secrets/** filter=git-crypt diff=git-crypt
+*output/** filter=git-crypt diff=git-crypt
+*logs/** filter=git-crypt diff=git-crypt
+*configuration/** filter=git-crypt diff=git-crypt
+`
+	err := e.project.WriteFile(".gitattributes", []byte(gitattributes))
+	if err != nil {
+		log.Errorf("error while creating .gitattributes: %v", err)
+		return err
+	}
+
+	gitignore := `.terraform
+bin
+`
+	err = e.project.WriteFile(".gitignore", []byte(gitignore))
+	if err != nil {
+		log.Errorf("error while creating .gitignore: %v", err)
+		return err
+	}
+
+	return nil
+}
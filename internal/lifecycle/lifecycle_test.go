@@ -0,0 +1,73 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lifecycle
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/briandowns/spinner"
+	"github.com/sighupio/furyctl/internal/configuration"
+	"github.com/sighupio/furyctl/internal/project"
+)
+
+func TestRun(t *testing.T) {
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+	s.Writer = ioutil.Discard
+	e := &Engine{kind: Kind{Name: "cluster"}, s: s}
+
+	var phases []Phase
+	record := func(phase Phase, err error) step {
+		return step{phase: phase, message: string(phase), run: func() error {
+			phases = append(phases, phase)
+			return err
+		}}
+	}
+	failure := errors.New("failure")
+
+	lenient := record(InstallFiles, failure)
+	lenient.lenient = true
+	err := e.run([]step{record(PrepareProject, nil), lenient, record(TerraformInit, nil), record(Apply, failure), record(Outputs, nil)})
+	if err != failure {
+		t.Errorf("run() error = %v, want %v", err, failure)
+	}
+	want := []Phase{PrepareProject, InstallFiles, TerraformInit, Apply}
+	if !reflect.DeepEqual(phases, want) {
+		t.Errorf("run() phases = %v, want %v", phases, want)
+	}
+}
+
+func TestRunFailingPreHook(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-lifecycle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	s := spinner.New(spinner.CharSets[11], 100*time.Millisecond)
+	s.Writer = ioutil.Discard
+	e := &Engine{
+		kind:    Kind{Name: "cluster"},
+		options: &Options{ProvisionerConfiguration: &configuration.Configuration{Kind: "Cluster", Provisioner: "eks"}},
+		project: &project.Project{Path: dir},
+		s:       s,
+	}
+
+	applied := false
+	steps := append(e.hooks("preApply", []string{"exit 1"}), step{phase: Apply, message: "apply", run: func() error {
+		applied = true
+		return nil
+	}})
+	if err = e.run(steps); err == nil || !strings.Contains(err.Error(), "preApply hook exit 1 failed") {
+		t.Errorf("run() error = %v, want the failing hook", err)
+	}
+	if applied {
+		t.Error("run() applied the project after a failing pre hook")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package lifecycle
+
+import "fmt"
+
+func (e *Engine) postInit() {
+	fmt.Printf(`%v
+[FURYCTL]
+
+Init phase completed.
+
+Project directory: %v
+Terraform logs: %v/logs/terraform.logs
+
+Everything ready to create the infrastructure; execute:
+
+$ furyctl %v apply
+
+`, e.provisioner.InitMessage(), e.project.Path, e.project.Path, e.kind.Name)
+}
+
+func (e *Engine) postUpdate() {
+	status, kubeconfig := "", ""
+	if e.kind.Kubeconfig {
+		status = " The Kubernetes Cluster is up to date."
+		kubeconfig = fmt.Sprintf(`Kubernetes configuration file: %v/secrets/kubeconfig
+
+Use it by running:
+$ export KUBECONFIG=%v/secrets/kubeconfig
+$ kubectl get nodes
+`, e.project.Path, e.project.Path)
+	}
+	fmt.Printf(`%v
+[FURYCTL]
+Apply phase completed.%v
+
+Project directory: %v
+Terraform logs: %v/logs/terraform.logs
+Output file: %v/output/output.json
+%v
+Everything is up to date.
+Ready to apply or destroy the infrastructure; execute:
+
+$ furyctl %v apply
+or
+$ furyctl %v destroy
+
+`, e.provisioner.UpdateMessage(), status, e.project.Path, e.project.Path, e.project.Path, kubeconfig, e.kind.Name, e.kind.Name)
+}
+
+func (e *Engine) postPlan() {
+	fmt.Printf(`[FURYCTL]
+Apply (dryrun) phase completed.
+Discover the upcoming changes in the terraform log file.
+
+Project directory: %v
+Terraform logs: %v/logs/terraform.logs
+
+Ready to apply or destroy the infrastructure; execute:
+
+$ furyctl %v apply
+or
+$ furyctl %v destroy
+
+`, e.project.Path, e.project.Path, e.kind.Name, e.kind.Name)
+}
+
+func (e *Engine) postDestroy() {
+	fmt.Printf(`%v
+[FURYCTL]
+Destroy phase completed.
+
+Project directory: %v
+Terraform logs: %v/logs/terraform.logs
+
+`, e.provisioner.DestroyMessage(), e.project.Path, e.project.Path)
+}
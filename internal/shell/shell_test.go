@@ -0,0 +1,60 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package shell
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "furyctl-shell")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	// resolve the symlinks of the temporary directory, e.g. /var -> /private/var, to compare it with pwd
+	dir, err = filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		timeout  time.Duration
+		want     string
+		errorMsg string
+	}{
+		{name: "output, directory and environment", command: `pwd; echo "$HOOK_NAME" >&2`, timeout: time.Minute, want: dir + "\npostApply\n"},
+		{name: "non-zero exit", command: "echo failing; exit 3", timeout: time.Minute, want: "failing\n", errorMsg: "exit status 3"},
+		{name: "timeout", command: "echo started; sleep 10 & wait", timeout: 100 * time.Millisecond, want: "started\n", errorMsg: "timed out after 100ms"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			start := time.Now()
+			err := Run(tt.command, dir, tt.timeout, []string{"HOOK_NAME=postApply"}, &out)
+			if tt.errorMsg == "" && err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+			if tt.errorMsg != "" && (err == nil || !strings.Contains(err.Error(), tt.errorMsg)) {
+				t.Errorf("Run() error = %v, want %q", err, tt.errorMsg)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Run() output = %q, want %q", out.String(), tt.want)
+			}
+			// the children of a timed out command are killed too, Run doesn't wait for them
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("Run() took %v", elapsed)
+			}
+		})
+	}
+}
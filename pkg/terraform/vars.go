@@ -0,0 +1,42 @@
+// Copyright (c) 2022 SIGHUP s.r.l All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package terraform
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// WriteVarFile writes the variables of a project as the name.tfvars.json file of the working directory,
+// returning its path. The variables are a struct with the json tags of the terraform variables
+func WriteVarFile(workingDir string, name string, variables interface{}) (string, error) {
+	content, err := json.MarshalIndent(variables, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := fmt.Sprintf("%v/%v.tfvars.json", workingDir, name)
+	err = ioutil.WriteFile(path, append(content, '\n'), 0600)
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Strings returns an empty list instead of a nil one, written as null in the var files
+func Strings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// StringMap returns an empty map instead of a nil one, written as null in the var files
+func StringMap(values map[string]string) map[string]string {
+	if values == nil {
+		return map[string]string{}
+	}
+	return values
+}